@@ -11,16 +11,25 @@ import (
 
 	"github.com/jeongpope/go-crix/logger"
 	"github.com/jeongpope/go-crix/model"
+	"github.com/jeongpope/go-crix/utils"
 )
 
 var (
 	EXCHANGE_KEY = []string{"UPBIT"}
 
+	// Declared queues, redeclared after reconnect.
+	// queue of other exchanges and index keys are declared on first publish
+	declared = make(map[string]bool)
+
 	c             *amqp.Connection
 	ch            *amqp.Channel
 	reconnectLock *sync.Mutex
 
-	chanReceive chan model.Ticker
+	chanReceive   chan model.Ticker
+	chanOrderBook chan model.OrderBook
+	chanTrade     chan model.Trade
+	chanMarket    chan model.MarketEvent
+	chanIndex     chan model.IndexValue
 )
 
 func Initialize() (err error) {
@@ -43,6 +52,10 @@ func Initialize() (err error) {
 
 	reconnectLock = &sync.Mutex{}
 	chanReceive = make(chan model.Ticker, 512)
+	chanOrderBook = make(chan model.OrderBook, 512)
+	chanTrade = make(chan model.Trade, 512)
+	chanMarket = make(chan model.MarketEvent, 64)
+	chanIndex = make(chan model.IndexValue, 512)
 
 	logger.Log.Println("[rabbitmq.go] Initialize Success")
 
@@ -80,18 +93,14 @@ func dial() (err error) {
 func declare() (err error) {
 	logger.Log.Println("[rabbitmq.go] declare")
 
-	for _, v := range EXCHANGE_KEY {
-		_, err := ch.QueueDeclare(
-			v,     // Name
-			false, // Durable
-			false, // Delete when unused
-			false, // exclusive
-			false, // no-wait
-			nil,   // arguments
-		)
+	queues := append([]string(nil), EXCHANGE_KEY...)
+	for k := range declared {
+		queues = append(queues, k)
+	}
 
+	for _, v := range queues {
+		err := declareQueue(v)
 		if err != nil {
-			logger.Log.Error("Failed to declare a queue")
 			return err
 		}
 	}
@@ -101,35 +110,48 @@ func declare() (err error) {
 	return err
 }
 
+func declareQueue(name string) error {
+	_, err := ch.QueueDeclare(
+		name,  // Name
+		false, // Durable
+		false, // Delete when unused
+		false, // exclusive
+		false, // no-wait
+		nil,   // arguments
+	)
+
+	if err != nil {
+		logger.Log.Error("Failed to declare a queue ", name)
+		return err
+	}
+
+	declared[name] = true
+	return nil
+}
+
 func Reconnect() (err error) {
 	logger.Log.Println("[rabbitmq.go] Reconnect")
 	reconnectLock.Lock()
 	ch.Close()
 	c.Close()
 
-	ticker := time.NewTicker(time.Second * 5)
-	for {
-		err = dial()
-		if err != nil {
-			logger.Log.Error("Failed to reconnect to RabbitMQ, retry after 10 second")
-			logger.Log.Error(err.Error())
-		}
+	policy := utils.DefaultRetryPolicy().WithOnRetry(func(attempt int, delay time.Duration, err error) {
+		logger.Log.Errorf("Failed to reconnect to RabbitMQ (attempt %d), retry after %s : %s", attempt, delay, err.Error())
+	})
 
-		err = declare()
+	err = policy.Retry(func() error {
+		err := dial()
 		if err != nil {
-			logger.Log.Error("Failed to declare a queue, retry after 10 second")
-			logger.Log.Error(err.Error())
-		} else {
-			logger.Log.Info("Successful reconnect")
-			break
+			return err
 		}
 
-		<-ticker.C
-	}
-
+		return declare()
+	})
 	reconnectLock.Unlock()
 
-	logger.Log.Println("[rabbitmq.go] Reconnect success")
+	if err == nil {
+		logger.Log.Println("[rabbitmq.go] Reconnect success")
+	}
 
 	return err
 }
@@ -151,16 +173,47 @@ func Release() {
 func Publish() (err error) {
 	logger.Log.Println("[rabbitmq.go] Publish")
 	for {
-		msg := <-chanReceive
-		jsonBytes, _ := json.Marshal(msg)
+		var queue, msgType string
+		var jsonBytes []byte
+
+		// Message type is set to AMQP type property, queue is exchange name (index key for index)
+		select {
+		case msg := <-chanReceive:
+			queue, msgType = msg.Exchange, "ticker"
+			jsonBytes, _ = json.Marshal(msg)
+		case msg := <-chanOrderBook:
+			queue, msgType = msg.Exchange, "orderbook"
+			jsonBytes, _ = json.Marshal(msg)
+		case msg := <-chanTrade:
+			queue, msgType = msg.Exchange, "trade"
+			jsonBytes, _ = json.Marshal(msg)
+		case msg := <-chanMarket:
+			queue, msgType = msg.Exchange, "market"
+			jsonBytes, _ = json.Marshal(msg)
+		case msg := <-chanIndex:
+			queue, msgType = msg.Key, "index"
+			if queue == "" {
+				queue = msg.Name
+			}
+			jsonBytes, _ = json.Marshal(msg)
+		}
+
+		// Default exchange drops message of undeclared queue
+		if !declared[queue] {
+			err = declareQueue(queue)
+			if err != nil {
+				logger.Log.Error(err.Error())
+			}
+		}
 
 		err = ch.Publish(
 			"",
-			msg.Exchange,
+			queue,
 			false,
 			false,
 			amqp.Publishing{
 				ContentType: "application/json",
+				Type:        msgType,
 				Body:        jsonBytes,
 			})
 
@@ -178,3 +231,19 @@ func Publish() (err error) {
 func GetChannel() chan model.Ticker {
 	return chanReceive
 }
+
+func GetOrderBookChannel() chan model.OrderBook {
+	return chanOrderBook
+}
+
+func GetTradeChannel() chan model.Trade {
+	return chanTrade
+}
+
+func GetMarketChannel() chan model.MarketEvent {
+	return chanMarket
+}
+
+func GetIndexChannel() chan model.IndexValue {
+	return chanIndex
+}
@@ -0,0 +1,267 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/jeongpope/go-crix/logger"
+	"github.com/jeongpope/go-crix/model"
+	"github.com/jeongpope/go-crix/utils"
+)
+
+const (
+	binanceQuoteAsset = "USDT" // collected quote asset
+	binanceSubsChunk  = 200    // streams per subscribe message
+)
+
+type Binance struct {
+	exchange
+
+	markets map[string]BinanceSymbol // symbol (ex. BTCUSDT) : symbol information
+}
+
+func init() {
+	register("BINANCE", func() IExchange { return new(Binance) })
+}
+
+// BinanceStreamEvent define combined stream wrapper
+type BinanceStreamEvent struct {
+	Stream string             `json:"stream"` // 스트림 이름 (ex. btcusdt@ticker)
+	Data   BinanceTickerEvent `json:"data"`   // 스트림 데이터
+}
+
+// BinanceTickerEvent define websocket 24hr ticker statistics event
+type BinanceTickerEvent struct {
+	Event              string `json:"e"` // 이벤트 타입 (24hrTicker)
+	EventTime          int64  `json:"E"` // 이벤트 시각 (milliseconds)
+	Symbol             string `json:"s"` // 심볼 (ex. BTCUSDT)
+	PriceChange        string `json:"p"` // 24시간 가격 변동
+	PriceChangePercent string `json:"P"` // 24시간 가격 변동률 (%)
+	WeightedAvgPrice   string `json:"w"` // 가중 평균가
+	PrevClosePrice     string `json:"x"` // 24시간 윈도우 이전 마지막 체결가
+	LastPrice          string `json:"c"` // 현재가
+	LastQty            string `json:"Q"` // 가장 최근 거래량
+	BidPrice           string `json:"b"` // 최우선 매수 호가
+	BidQty             string `json:"B"` // 최우선 매수 잔량
+	AskPrice           string `json:"a"` // 최우선 매도 호가
+	AskQty             string `json:"A"` // 최우선 매도 잔량
+	OpenPrice          string `json:"o"` // 시가
+	HighPrice          string `json:"h"` // 고가
+	LowPrice           string `json:"l"` // 저가
+	Volume             string `json:"v"` // 24시간 누적 거래량 (base asset)
+	QuoteVolume        string `json:"q"` // 24시간 누적 거래대금 (quote asset)
+	OpenTime           int64  `json:"O"` // 통계 시작 시각
+	CloseTime          int64  `json:"C"` // 통계 종료 시각
+	FirstID            int64  `json:"F"` // 첫 거래 ID
+	LastID             int64  `json:"L"` // 마지막 거래 ID
+	Count              int64  `json:"n"` // 거래 수
+}
+
+// BinanceSubsField define binance websocket subscribe request
+type BinanceSubsField struct {
+	Method string   `json:"method"`
+	Params []string `json:"params"`
+	ID     int      `json:"id"`
+}
+
+// BinanceSymbol define binance symbol information
+type BinanceSymbol struct {
+	Symbol     string `json:"symbol"`     // 심볼 (ex. BTCUSDT)
+	Status     string `json:"status"`     // 거래 상태 (TRADING)
+	BaseAsset  string `json:"baseAsset"`  // 기준 자산 (ex. BTC)
+	QuoteAsset string `json:"quoteAsset"` // 호가 자산 (ex. USDT)
+}
+
+func (ex *Binance) Initialize(currencies *[]string) []string {
+	logger.Log.Info("[binance.go] Start Initialize()")
+
+	symbols, coins := ex.getMarkets()
+
+	ex.tickerEndpoint = binanceTickerURL
+	ex.c = nil
+	ex.reconnectLock = &sync.Mutex{}
+	ex.subsMessage = ex.makeSubsMessage(symbols)
+	ex.chanSendMessage = nil
+
+	ex.supportAssets = append(ex.supportAssets, coins...)
+	ex.tickers = make(map[string]model.Ticker)
+	ex.updateLock = nil
+
+	ex.snapshotCodes = symbols
+	ex.initSnapshot(symbols)
+
+	logger.Log.Info("[binance.go] End Initialize()")
+
+	return ex.supportAssets
+}
+
+func (ex *Binance) Execute(ctx context.Context) (err error) {
+	logger.Log.Info("[binance.go] Start Execute()")
+
+	handler := func(event *BinanceTickerEvent) {
+		market, ok := ex.markets[event.Symbol]
+		if !ok {
+			return
+		}
+
+		price := utils.ToFloat64(event.LastPrice)
+
+		if ex.tickers[event.Symbol].Price != price {
+			tempTicker := model.Ticker{
+				Exchange:       "BINANCE",
+				Currency:       market.BaseAsset,
+				Quote:          market.QuoteAsset,
+				Price:          price,
+				YesterdayPrice: utils.ToFloat64(event.PrevClosePrice),
+				Change:         utils.ToFloat64(event.PriceChange),
+				ChangeRate:     utils.ToFloat64(event.PriceChangePercent) / 100,
+				Volume:         uint(utils.ToFloat64(event.QuoteVolume)),
+			}
+
+			ex.tickers[event.Symbol] = tempTicker
+			logger.Log.Info("[TICKER] ", tempTicker)
+
+			ex.chanSendMessage <- tempTicker
+		}
+	}
+
+	errHandler := func(err error) {
+		logger.Log.Error("Binance subscribeTicker() return error : ", err)
+	}
+
+	// Serve
+	wsHandler := func(message []byte) {
+		event := new(BinanceStreamEvent)
+		err := json.Unmarshal(message, event)
+
+		if err != nil {
+			errHandler(err)
+			return
+		}
+
+		// Skip subscribe responses ({"result":null,"id":1})
+		if event.Stream == "" {
+			return
+		}
+
+		handler(&event.Data)
+	}
+
+	cfg := &wsConfig{
+		endpoint:    ex.tickerEndpoint,
+		subsMessage: ex.subsMessage,
+		resync: func() {
+			ex.resyncSnapshot(func() { ex.initSnapshot(ex.snapshotCodes) })
+		},
+	}
+
+	return websocketServe(ctx, ex.c, ex.reconnectLock, cfg, wsHandler, errHandler)
+}
+
+func (ex *Binance) Release() {
+	logger.Log.Info("[binance.go] Start Release()")
+
+	if ex.c != nil {
+		ex.c.Close()
+	}
+
+	logger.Log.Info("[binance.go] End Release()")
+}
+
+func (ex *Binance) initSnapshot(symbols []string) {
+	logger.Log.Info("[binance.go] Start initSnapshot()")
+
+	data, err := restGet(binanceSnapshotURL)
+	if err != nil {
+		logger.Log.Error("Binance initSnapshot() failed : ", err)
+		return
+	}
+
+	// Parse JSON
+	var f []map[string]interface{}
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		logger.Log.Error("Binance initSnapshot() error parsing JSON: ", err)
+		return
+	}
+
+	for _, dataMap := range f {
+		symbol, _ := dataMap["symbol"].(string)
+
+		market, ok := ex.markets[symbol]
+		if !ok {
+			continue
+		}
+
+		ex.tickers[symbol] = model.Ticker{
+			Exchange:       "BINANCE",
+			Currency:       market.BaseAsset,
+			Quote:          market.QuoteAsset,
+			Price:          utils.ToFloat64(dataMap["lastPrice"]),
+			YesterdayPrice: utils.ToFloat64(dataMap["prevClosePrice"]),
+			Change:         utils.ToFloat64(dataMap["priceChange"]),
+			ChangeRate:     utils.ToFloat64(dataMap["priceChangePercent"]) / 100,
+			Volume:         uint(utils.ToFloat64(dataMap["quoteVolume"])),
+		}
+	}
+
+	logger.Log.Info("[binance.go] End initSnapshot()")
+}
+
+// -----
+func (ex *Binance) getMarkets() ([]string, []string) {
+	var symbols []string
+	var currencies []string
+
+	var info struct {
+		Symbols []BinanceSymbol `json:"symbols"`
+	}
+
+	err := restGetJSON("Binance getMarkets()", binanceMarketURL, &info)
+	if err != nil {
+		logger.Log.Error("Binance getMarkets() failed : ", err)
+		return nil, nil
+	}
+
+	ex.markets = make(map[string]BinanceSymbol)
+	for _, v := range info.Symbols {
+		if v.Status != "TRADING" || v.QuoteAsset != binanceQuoteAsset {
+			continue
+		}
+
+		ex.markets[v.Symbol] = v
+		symbols = append(symbols, v.Symbol)
+		currencies = append(currencies, v.BaseAsset)
+	}
+
+	return symbols, currencies
+}
+
+func (ex *Binance) makeSubsMessage(symbols []string) []*[]byte {
+	// Subscribe message format, split by binanceSubsChunk streams
+	// {"method":"SUBSCRIBE", "params":["btcusdt@ticker"], "id":1}
+	var msgs []*[]byte
+
+	for i := 0; i < len(symbols); i += binanceSubsChunk {
+		end := i + binanceSubsChunk
+		if end > len(symbols) {
+			end = len(symbols)
+		}
+
+		var params []string
+		for _, v := range symbols[i:end] {
+			params = append(params, strings.ToLower(v)+"@ticker")
+		}
+
+		msg, _ := json.Marshal(BinanceSubsField{
+			Method: "SUBSCRIBE",
+			Params: params,
+			ID:     len(msgs) + 1,
+		})
+		msgs = append(msgs, &msg)
+	}
+
+	return msgs
+}
@@ -0,0 +1,239 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/jeongpope/go-crix/logger"
+	"github.com/jeongpope/go-crix/model"
+	"github.com/jeongpope/go-crix/utils"
+)
+
+type Bithumb struct {
+	exchange
+}
+
+func init() {
+	register("BITHUMB", func() IExchange { return new(Bithumb) })
+}
+
+// BithumbTickerEvent define websocket ticker statistics event
+type BithumbTickerEvent struct {
+	Type    string             `json:"type"`    // 타입(ticker : 현재가)
+	Content BithumbTickerField `json:"content"` // 현재가 정보
+}
+
+// BithumbTickerField define bithumb websocket ticker content
+type BithumbTickerField struct {
+	Symbol         string `json:"symbol"`         // 통화 코드 (ex. BTC_KRW)
+	TickType       string `json:"tickType"`       // 변동 기준시간 (30M, 1H, 12H, 24H, MID)
+	Date           string `json:"date"`           // 일자
+	Time           string `json:"time"`           // 시간
+	OpenPrice      string `json:"openPrice"`      // 시가
+	ClosePrice     string `json:"closePrice"`     // 종가(현재가)
+	LowPrice       string `json:"lowPrice"`       // 저가
+	HighPrice      string `json:"highPrice"`      // 고가
+	Value          string `json:"value"`          // 누적 거래금액
+	Volume         string `json:"volume"`         // 누적 거래량
+	SellVolume     string `json:"sellVolume"`     // 매도 누적 거래량
+	BuyVolume      string `json:"buyVolume"`      // 매수 누적 거래량
+	PrevClosePrice string `json:"prevClosePrice"` // 전일 종가
+	ChgRate        string `json:"chgRate"`        // 변동률 (%)
+	ChgAmt         string `json:"chgAmt"`         // 변동금액
+	VolumePower    string `json:"volumePower"`    // 체결 강도
+}
+
+// BithumbSubsField define bithumb websocket subscribe request
+type BithumbSubsField struct {
+	Type      string   `json:"type"`
+	Symbols   []string `json:"symbols"`
+	TickTypes []string `json:"tickTypes"`
+}
+
+// BithumbMarket define bithumb market information
+type BithumbMarket struct {
+	Market        string `json:"market"`         // 빗썸 제공 시장 정보 (ex. KRW-BTC)
+	Korean        string `json:"korean_name"`    // 한글명
+	English       string `json:"english_name"`   // 영문명
+	MarketWarning string `json:"market_warning"` // 유의 종목 여부
+}
+
+func (ex *Bithumb) Initialize(currencies *[]string) []string {
+	logger.Log.Info("[bithumb.go] Start Initialize()")
+
+	symbols, coins := ex.getMarkets()
+	msg := ex.makeSubsMessage(symbols)
+
+	ex.tickerEndpoint = bithumbTickerURL
+	ex.c = nil
+	ex.reconnectLock = &sync.Mutex{}
+	ex.subsMessage = append(ex.subsMessage, &msg)
+	ex.chanSendMessage = nil
+
+	ex.supportAssets = append(ex.supportAssets, coins...)
+	ex.tickers = make(map[string]model.Ticker)
+	ex.updateLock = nil
+
+	ex.snapshotCodes = symbols
+	ex.initSnapshot(symbols)
+
+	logger.Log.Info("[bithumb.go] End Initialize()")
+
+	return ex.supportAssets
+}
+
+func (ex *Bithumb) Execute(ctx context.Context) (err error) {
+	logger.Log.Info("[bithumb.go] Start Execute()")
+
+	handler := func(content *BithumbTickerField) {
+		price := utils.ToFloat64(content.ClosePrice)
+
+		if ex.tickers[content.Symbol].Price != price {
+			tempTicker := model.Ticker{
+				Exchange:       "BITHUMB",
+				Currency:       strings.TrimSuffix(content.Symbol, "_KRW"),
+				Quote:          "KRW",
+				Price:          price,
+				YesterdayPrice: utils.ToFloat64(content.PrevClosePrice),
+				Change:         utils.ToFloat64(content.ChgAmt),
+				ChangeRate:     utils.ToFloat64(content.ChgRate) / 100,
+				Volume:         uint(utils.ToFloat64(content.Value)),
+			}
+
+			ex.tickers[content.Symbol] = tempTicker
+			logger.Log.Info("[TICKER] ", tempTicker)
+
+			ex.chanSendMessage <- tempTicker
+		}
+	}
+
+	errHandler := func(err error) {
+		logger.Log.Error("Bithumb subscribeTicker() return error : ", err)
+	}
+
+	// Serve
+	wsHandler := func(message []byte) {
+		event := new(BithumbTickerEvent)
+		err := json.Unmarshal(message, event)
+
+		if err != nil {
+			errHandler(err)
+			return
+		}
+
+		// Skip connect, subscribe status messages
+		if event.Type != "ticker" {
+			return
+		}
+
+		handler(&event.Content)
+	}
+
+	cfg := &wsConfig{
+		endpoint:    ex.tickerEndpoint,
+		subsMessage: ex.subsMessage,
+		resync: func() {
+			ex.resyncSnapshot(func() { ex.initSnapshot(ex.snapshotCodes) })
+		},
+	}
+
+	return websocketServe(ctx, ex.c, ex.reconnectLock, cfg, wsHandler, errHandler)
+}
+
+func (ex *Bithumb) Release() {
+	logger.Log.Info("[bithumb.go] Start Release()")
+
+	if ex.c != nil {
+		ex.c.Close()
+	}
+
+	logger.Log.Info("[bithumb.go] End Release()")
+}
+
+func (ex *Bithumb) initSnapshot(symbols []string) {
+	logger.Log.Info("[bithumb.go] Start initSnapshot()")
+
+	data, err := restGet(bithumbSnapshotURL)
+	if err != nil {
+		logger.Log.Error("Bithumb initSnapshot() failed : ", err)
+		return
+	}
+
+	// Parse JSON, data contains "date" field next to currencies
+	var f struct {
+		Status string                     `json:"status"`
+		Data   map[string]json.RawMessage `json:"data"`
+	}
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		logger.Log.Error("Bithumb initSnapshot() error parsing JSON: ", err)
+		return
+	}
+
+	for _, symbol := range symbols {
+		currency := strings.TrimSuffix(symbol, "_KRW")
+
+		raw, ok := f.Data[currency]
+		if !ok {
+			continue
+		}
+
+		var dataMap map[string]interface{}
+		if json.Unmarshal(raw, &dataMap) != nil {
+			continue
+		}
+
+		ex.tickers[symbol] = model.Ticker{
+			Exchange:       "BITHUMB",
+			Currency:       currency,
+			Quote:          "KRW",
+			Price:          utils.ToFloat64(dataMap["closing_price"]),
+			YesterdayPrice: utils.ToFloat64(dataMap["prev_closing_price"]),
+			Change:         utils.ToFloat64(dataMap["fluctate_24H"]),
+			ChangeRate:     utils.ToFloat64(dataMap["fluctate_rate_24H"]) / 100,
+			Volume:         uint(utils.ToFloat64(dataMap["acc_trade_value_24H"])),
+		}
+	}
+
+	logger.Log.Info("[bithumb.go] End initSnapshot()")
+}
+
+// -----
+func (ex *Bithumb) getMarkets() ([]string, []string) {
+	var symbols []string
+	var currencies []string
+
+	var markets []BithumbMarket
+
+	err := restGetJSON("Bithumb getMarkets()", bithumbMarketURL, &markets)
+	if err != nil {
+		logger.Log.Error("Bithumb getMarkets() failed : ", err)
+		return nil, nil
+	}
+
+	for _, market := range markets {
+		if strings.HasPrefix(market.Market, "KRW-") {
+			currency := market.Market[len("KRW-"):]
+
+			symbols = append(symbols, currency+"_KRW")
+			currencies = append(currencies, currency)
+		}
+	}
+
+	return symbols, currencies
+}
+
+func (ex *Bithumb) makeSubsMessage(symbols []string) []byte {
+	// Subscribe message format
+	// {"type":"ticker", "symbols":["BTC_KRW"], "tickTypes":["24H"]}
+	msg := BithumbSubsField{
+		Type:      "ticker",
+		Symbols:   symbols,
+		TickTypes: []string{"24H"},
+	}
+	tickerMsg, _ := json.Marshal(msg)
+
+	return tickerMsg
+}
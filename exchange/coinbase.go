@@ -0,0 +1,393 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jeongpope/go-crix/logger"
+	"github.com/jeongpope/go-crix/model"
+	"github.com/jeongpope/go-crix/utils"
+)
+
+const (
+	coinbaseQuoteAsset     = "USD"                  // collected quote asset
+	coinbaseRequestDelay   = time.Millisecond * 150 // public REST limit is 10 requests per second
+	coinbaseResyncInterval = time.Second * 10       // minimum resync interval per product
+)
+
+type Coinbase struct {
+	exchange
+
+	markets   map[string]CoinbaseProduct // product id (ex. BTC-USD) : product information
+	sequences map[string]int64           // product id : last sequence, guarded by updateLock
+
+	resyncLock   *sync.Mutex
+	resyncingAll int32                // resyncAll running, accessed atomically
+	resyncing    map[string]bool      // product id : resync pending
+	resyncedAt   map[string]time.Time // product id : last resync time
+}
+
+func init() {
+	register("COINBASE", func() IExchange { return new(Coinbase) })
+}
+
+// CoinbaseTickerEvent define websocket ticker channel event
+type CoinbaseTickerEvent struct {
+	Type      string `json:"type"`       // 타입 (ticker, subscriptions, error)
+	Message   string `json:"message"`    // 에러 메시지
+	Sequence  int64  `json:"sequence"`   // 상품별 시퀀스
+	ProductID string `json:"product_id"` // 상품 ID (ex. BTC-USD)
+	Price     string `json:"price"`      // 현재가
+	Open24h   string `json:"open_24h"`   // 24시간 시가
+	Volume24h string `json:"volume_24h"` // 24시간 누적 거래량 (base currency)
+	Low24h    string `json:"low_24h"`    // 24시간 저가
+	High24h   string `json:"high_24h"`   // 24시간 고가
+	Volume30d string `json:"volume_30d"` // 30일 누적 거래량
+	BestBid   string `json:"best_bid"`   // 최우선 매수 호가
+	BestAsk   string `json:"best_ask"`   // 최우선 매도 호가
+	Side      string `json:"side"`       // 체결 방향 (buy, sell)
+	Time      string `json:"time"`       // 체결 시각
+	TradeID   int64  `json:"trade_id"`   // 체결 ID
+	LastSize  string `json:"last_size"`  // 최근 체결량
+}
+
+// CoinbaseSubsField define coinbase websocket subscribe request
+type CoinbaseSubsField struct {
+	Type       string   `json:"type"`
+	ProductIDs []string `json:"product_ids"`
+	Channels   []string `json:"channels"`
+}
+
+// CoinbaseProduct define coinbase product information
+type CoinbaseProduct struct {
+	ID              string `json:"id"`               // 상품 ID (ex. BTC-USD)
+	BaseCurrency    string `json:"base_currency"`    // 기준 자산 (ex. BTC)
+	QuoteCurrency   string `json:"quote_currency"`   // 호가 자산 (ex. USD)
+	Status          string `json:"status"`           // 상태 (online)
+	TradingDisabled bool   `json:"trading_disabled"` // 거래 정지 여부
+}
+
+// CoinbaseStats define coinbase 24h product stats
+type CoinbaseStats struct {
+	Open   string `json:"open"`   // 24시간 시가
+	High   string `json:"high"`   // 24시간 고가
+	Low    string `json:"low"`    // 24시간 저가
+	Last   string `json:"last"`   // 현재가
+	Volume string `json:"volume"` // 24시간 누적 거래량 (base currency)
+}
+
+func (ex *Coinbase) Initialize(currencies *[]string) []string {
+	logger.Log.Info("[coinbase.go] Start Initialize()")
+
+	productIDs, coins := ex.getMarkets()
+	msg := ex.makeSubsMessage(productIDs)
+
+	ex.tickerEndpoint = coinbaseTickerURL
+	ex.c = nil
+	ex.reconnectLock = &sync.Mutex{}
+	ex.subsMessage = append(ex.subsMessage, &msg)
+	ex.chanSendMessage = nil
+
+	ex.supportAssets = append(ex.supportAssets, coins...)
+	ex.tickers = make(map[string]model.Ticker)
+	ex.updateLock = &sync.Mutex{}
+
+	ex.sequences = make(map[string]int64)
+	ex.resyncLock = &sync.Mutex{}
+	ex.resyncing = make(map[string]bool)
+	ex.resyncedAt = make(map[string]time.Time)
+
+	ex.initSnapshot(productIDs)
+
+	logger.Log.Info("[coinbase.go] End Initialize()")
+
+	return ex.supportAssets
+}
+
+func (ex *Coinbase) Execute(ctx context.Context) (err error) {
+	logger.Log.Info("[coinbase.go] Start Execute()")
+
+	errHandler := func(err error) {
+		logger.Log.Error("Coinbase subscribeTicker() return error : ", err)
+	}
+
+	// Serve
+	wsHandler := func(message []byte) {
+		event := new(CoinbaseTickerEvent)
+		err := json.Unmarshal(message, event)
+
+		if err != nil {
+			errHandler(err)
+			return
+		}
+
+		switch event.Type {
+		case "ticker":
+			ex.handleTicker(event)
+		case "error":
+			logger.Log.Error("Coinbase error response : ", event.Message)
+		}
+	}
+
+	// Snapshot of every product after reconnect and while websocket is unavailable
+	cfg := &wsConfig{
+		endpoint:    ex.tickerEndpoint,
+		subsMessage: ex.subsMessage,
+		resync:      ex.resyncAll,
+	}
+
+	return websocketServe(ctx, ex.c, ex.reconnectLock, cfg, wsHandler, errHandler)
+}
+
+// handleTicker update ticker of product,
+// ticker channel skips sequences between messages so only out of order sequence requests resync
+func (ex *Coinbase) handleTicker(event *CoinbaseTickerEvent) {
+	market, ok := ex.markets[event.ProductID]
+	if !ok {
+		return
+	}
+
+	last, ok := ex.sequences[event.ProductID]
+	if ok && event.Sequence <= last {
+		logger.Log.Errorf("Coinbase %s out of order sequence %d, last %d",
+			event.ProductID, event.Sequence, last)
+
+		ex.requestResync(event.ProductID)
+		return
+	}
+
+	// Sequence is read by resync to drop snapshot older than websocket
+	ex.updateLock.Lock()
+	ex.sequences[event.ProductID] = event.Sequence
+	ex.updateLock.Unlock()
+
+	price := utils.ToFloat64(event.Price)
+	ex.update(event.ProductID, ex.toTicker(market, price,
+		utils.ToFloat64(event.Open24h), utils.ToFloat64(event.Volume24h)))
+}
+
+// resyncAll snapshot every product in background, REST request per product takes long
+// so websocket reader is not blocked. run is skipped while previous one is running
+func (ex *Coinbase) resyncAll() {
+	if !atomic.CompareAndSwapInt32(&ex.resyncingAll, 0, 1) {
+		return
+	}
+
+	go func() {
+		defer atomic.StoreInt32(&ex.resyncingAll, 0)
+
+		tTicker := time.NewTicker(coinbaseRequestDelay)
+		defer tTicker.Stop()
+
+		for id := range ex.markets {
+			ex.updateLock.Lock()
+			sequence := ex.sequences[id]
+			ex.updateLock.Unlock()
+
+			ticker, err := ex.getSnapshot(id)
+			if err != nil {
+				logger.Log.Errorf("Coinbase resync %s failed : %s", id, err.Error())
+			} else if ex.updateSnapshot(id, ticker, sequence) {
+				logger.Log.Infof("[coinbase.go] %s resynced", id)
+			}
+
+			<-tTicker.C
+		}
+	}()
+}
+
+func (ex *Coinbase) Release() {
+	logger.Log.Info("[coinbase.go] Start Release()")
+
+	if ex.c != nil {
+		ex.c.Close()
+	}
+
+	logger.Log.Info("[coinbase.go] End Release()")
+}
+
+func (ex *Coinbase) initSnapshot(productIDs []string) {
+	logger.Log.Info("[coinbase.go] Start initSnapshot()")
+
+	tTicker := time.NewTicker(coinbaseRequestDelay)
+	defer tTicker.Stop()
+
+	for _, id := range productIDs {
+		ticker, err := ex.getSnapshot(id)
+		if err != nil {
+			logger.Log.Errorf("Coinbase initSnapshot() %s failed : %s", id, err.Error())
+		} else {
+			ex.tickers[id] = ticker
+		}
+
+		<-tTicker.C
+	}
+
+	logger.Log.Info("[coinbase.go] End initSnapshot()")
+}
+
+// -----
+// update store ticker and send if price changed
+func (ex *Coinbase) update(id string, ticker model.Ticker) {
+	ex.updateLock.Lock()
+	changed := ex.tickers[id].Price != ticker.Price
+	if changed {
+		ex.tickers[id] = ticker
+	}
+	ex.updateLock.Unlock()
+
+	if changed {
+		logger.Log.Info("[TICKER] ", ticker)
+
+		ex.chanSendMessage <- ticker
+	}
+}
+
+// requestResync schedule REST resync of product ticker,
+// requests while resync is pending are merged into the pending one
+func (ex *Coinbase) requestResync(id string) {
+	ex.resyncLock.Lock()
+	if ex.resyncing[id] {
+		ex.resyncLock.Unlock()
+		return
+	}
+	ex.resyncing[id] = true
+	wait := time.Until(ex.resyncedAt[id].Add(coinbaseResyncInterval))
+	ex.resyncLock.Unlock()
+
+	go func() {
+		if wait > 0 {
+			time.Sleep(wait)
+		}
+
+		ex.resyncLock.Lock()
+		ex.resyncing[id] = false
+		ex.resyncedAt[id] = time.Now()
+		ex.resyncLock.Unlock()
+
+		// Websocket message received while requesting is newer than snapshot
+		ex.updateLock.Lock()
+		sequence := ex.sequences[id]
+		ex.updateLock.Unlock()
+
+		ticker, err := ex.getSnapshot(id)
+		if err != nil {
+			logger.Log.Errorf("Coinbase resync %s failed : %s", id, err.Error())
+			return
+		}
+
+		if !ex.updateSnapshot(id, ticker, sequence) {
+			logger.Log.Infof("[coinbase.go] %s resync dropped, newer websocket data arrived", id)
+			return
+		}
+
+		logger.Log.Infof("[coinbase.go] %s resynced", id)
+	}()
+}
+
+// updateSnapshot store snapshot ticker and send if price changed,
+// false if websocket sequence moved since snapshot was requested
+func (ex *Coinbase) updateSnapshot(id string, ticker model.Ticker, sequence int64) bool {
+	ex.updateLock.Lock()
+	if ex.sequences[id] != sequence {
+		ex.updateLock.Unlock()
+		return false
+	}
+
+	changed := ex.tickers[id].Price != ticker.Price
+	if changed {
+		ex.tickers[id] = ticker
+	}
+	ex.updateLock.Unlock()
+
+	if changed {
+		logger.Log.Info("[TICKER] ", ticker)
+
+		ex.chanSendMessage <- ticker
+	}
+
+	return true
+}
+
+func (ex *Coinbase) getSnapshot(id string) (model.Ticker, error) {
+	market, ok := ex.markets[id]
+	if !ok {
+		return model.Ticker{}, fmt.Errorf("unknown product %s", id)
+	}
+
+	data, err := restGet(fmt.Sprintf(coinbaseSnapshotURL, id))
+	if err != nil {
+		return model.Ticker{}, err
+	}
+
+	var stats CoinbaseStats
+	err = json.Unmarshal(data, &stats)
+	if err != nil {
+		return model.Ticker{}, err
+	}
+
+	return ex.toTicker(market, utils.ToFloat64(stats.Last),
+		utils.ToFloat64(stats.Open), utils.ToFloat64(stats.Volume)), nil
+}
+
+func (ex *Coinbase) toTicker(market CoinbaseProduct, price float64, open float64, volume float64) model.Ticker {
+	// Coinbase provides 24h rolling open price only
+	var changeRate float64
+	if open != 0 {
+		changeRate = (price - open) / open
+	}
+
+	return model.Ticker{
+		Exchange:       "COINBASE",
+		Currency:       market.BaseCurrency,
+		Quote:          market.QuoteCurrency,
+		Price:          price,
+		YesterdayPrice: open,
+		Change:         price - open,
+		ChangeRate:     changeRate,
+		Volume:         uint(volume * price),
+	}
+}
+
+func (ex *Coinbase) getMarkets() ([]string, []string) {
+	var productIDs []string
+	var currencies []string
+
+	var products []CoinbaseProduct
+
+	err := restGetJSON("Coinbase getMarkets()", coinbaseMarketURL, &products)
+	if err != nil {
+		logger.Log.Error("Coinbase getMarkets() failed : ", err)
+		return nil, nil
+	}
+
+	ex.markets = make(map[string]CoinbaseProduct)
+	for _, v := range products {
+		if v.Status != "online" || v.TradingDisabled || v.QuoteCurrency != coinbaseQuoteAsset {
+			continue
+		}
+
+		ex.markets[v.ID] = v
+		productIDs = append(productIDs, v.ID)
+		currencies = append(currencies, v.BaseCurrency)
+	}
+
+	return productIDs, currencies
+}
+
+func (ex *Coinbase) makeSubsMessage(productIDs []string) []byte {
+	// Subscribe message format
+	// {"type":"subscribe", "product_ids":["BTC-USD"], "channels":["ticker"]}
+	msg := CoinbaseSubsField{
+		Type:       "subscribe",
+		ProductIDs: productIDs,
+		Channels:   []string{"ticker"},
+	}
+	tickerMsg, _ := json.Marshal(msg)
+
+	return tickerMsg
+}
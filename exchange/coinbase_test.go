@@ -0,0 +1,56 @@
+package exchange
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jeongpope/go-crix/model"
+)
+
+func Test_CoinbaseUpdateSnapshot(t *testing.T) {
+	ex := new(Coinbase)
+	ex.updateLock = &sync.Mutex{}
+	ex.tickers = map[string]model.Ticker{"BTC-USD": {Price: 100}}
+	ex.sequences = map[string]int64{"BTC-USD": 10}
+	ex.chanSendMessage = make(chan model.Ticker, 1)
+
+	// Websocket sequence moved while snapshot was requested
+	ex.sequences["BTC-USD"] = 11
+	if ex.updateSnapshot("BTC-USD", model.Ticker{Price: 90}, 10) || ex.tickers["BTC-USD"].Price != 100 {
+		t.Errorf("expected older snapshot dropped, price %f", ex.tickers["BTC-USD"].Price)
+	}
+
+	if !ex.updateSnapshot("BTC-USD", model.Ticker{Price: 90}, 11) || (<-ex.chanSendMessage).Price != 90 {
+		t.Error("expected snapshot applied")
+	}
+}
+
+func Test_CoinbaseHandleTickerSequence(t *testing.T) {
+	ex := new(Coinbase)
+	ex.updateLock = &sync.Mutex{}
+	ex.tickers = make(map[string]model.Ticker)
+	ex.markets = map[string]CoinbaseProduct{"BTC-USD": {ID: "BTC-USD", BaseCurrency: "BTC", QuoteCurrency: "USD"}}
+	ex.sequences = make(map[string]int64)
+	ex.chanSendMessage = make(chan model.Ticker, 4)
+	ex.resyncLock = &sync.Mutex{}
+	ex.resyncing = make(map[string]bool)
+	// Pending resync waits resync interval, no request is sent during test
+	ex.resyncedAt = map[string]time.Time{"BTC-USD": time.Now()}
+
+	// Ticker channel skips sequences, gap is not resynced
+	ex.handleTicker(&CoinbaseTickerEvent{Type: "ticker", ProductID: "BTC-USD", Sequence: 10, Price: "100"})
+	ex.handleTicker(&CoinbaseTickerEvent{Type: "ticker", ProductID: "BTC-USD", Sequence: 25, Price: "110"})
+	if ex.resyncing["BTC-USD"] || ex.tickers["BTC-USD"].Price != 110 {
+		t.Fatalf("unexpected resync of sequence gap, price %f", ex.tickers["BTC-USD"].Price)
+	}
+
+	// Out of order sequence is dropped and resynced
+	ex.handleTicker(&CoinbaseTickerEvent{Type: "ticker", ProductID: "BTC-USD", Sequence: 20, Price: "90"})
+	ex.resyncLock.Lock()
+	resyncing := ex.resyncing["BTC-USD"]
+	ex.resyncLock.Unlock()
+	if !resyncing || ex.tickers["BTC-USD"].Price != 110 {
+		t.Errorf("expected out of order ticker dropped and resync requested, price %f", ex.tickers["BTC-USD"].Price)
+	}
+}
@@ -0,0 +1,279 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeongpope/go-crix/logger"
+	"github.com/jeongpope/go-crix/model"
+	"github.com/jeongpope/go-crix/utils"
+)
+
+const (
+	coinoneKeepaliveInterval = time.Minute * 10 // server closes connection idle for 30 minute
+)
+
+type Coinone struct {
+	exchange
+}
+
+func init() {
+	register("COINONE", func() IExchange { return new(Coinone) })
+}
+
+// CoinoneTickerEvent define websocket ticker event
+type CoinoneTickerEvent struct {
+	ResponseType string             `json:"response_type"` // 응답 타입 (DATA, PONG, SUBSCRIBED, ERROR)
+	Channel      string             `json:"channel"`       // 채널 (TICKER)
+	Data         CoinoneTickerField `json:"data"`          // 현재가 정보
+}
+
+// CoinoneTickerField define coinone ticker
+type CoinoneTickerField struct {
+	QuoteCurrency  string `json:"quote_currency"`  // 기준 통화 (ex. KRW)
+	TargetCurrency string `json:"target_currency"` // 종목 (ex. BTC)
+	Timestamp      int64  `json:"timestamp"`       // 타임스탬프 (milliseconds)
+	QuoteVolume    string `json:"quote_volume"`    // 24시간 누적 거래대금
+	TargetVolume   string `json:"target_volume"`   // 24시간 누적 거래량
+	High           string `json:"high"`            // 고가
+	Low            string `json:"low"`             // 저가
+	First          string `json:"first"`           // 시가
+	Last           string `json:"last"`            // 현재가
+	YesterdayLast  string `json:"yesterday_last"`  // 전일 종가
+}
+
+// CoinoneSubsField define coinone websocket subscribe request
+type CoinoneSubsField struct {
+	RequestType string            `json:"request_type"`
+	Channel     string            `json:"channel"`
+	Topic       CoinoneTopicField `json:"topic"`
+}
+
+// CoinoneTopicField define coinone websocket subscribe topic
+type CoinoneTopicField struct {
+	QuoteCurrency  string `json:"quote_currency"`
+	TargetCurrency string `json:"target_currency"`
+}
+
+// CoinoneMarket define coinone market information
+type CoinoneMarket struct {
+	QuoteCurrency  string `json:"quote_currency"`  // 기준 통화 (ex. KRW)
+	TargetCurrency string `json:"target_currency"` // 종목 (ex. BTC)
+	TradeStatus    int    `json:"trade_status"`    // 거래 상태 (1 : 정상)
+}
+
+func (ex *Coinone) Initialize(currencies *[]string) []string {
+	logger.Log.Info("[coinone.go] Start Initialize()")
+
+	targets, coins := ex.getMarkets()
+
+	ex.tickerEndpoint = coinoneTickerURL
+	ex.c = nil
+	ex.reconnectLock = &sync.Mutex{}
+	ex.subsMessage = ex.makeSubsMessage(targets)
+	ex.chanSendMessage = nil
+
+	ex.supportAssets = append(ex.supportAssets, coins...)
+	ex.tickers = make(map[string]model.Ticker)
+	ex.updateLock = nil
+
+	ex.snapshotCodes = targets
+	ex.initSnapshot(targets)
+
+	logger.Log.Info("[coinone.go] End Initialize()")
+
+	return ex.supportAssets
+}
+
+func (ex *Coinone) Execute(ctx context.Context) (err error) {
+	logger.Log.Info("[coinone.go] Start Execute()")
+
+	handler := func(data *CoinoneTickerField) {
+		target := strings.ToUpper(data.TargetCurrency)
+		price := utils.ToFloat64(data.Last)
+
+		if ex.tickers[target].Price != price {
+			tempTicker := ex.toTicker(data)
+
+			ex.tickers[target] = tempTicker
+			logger.Log.Info("[TICKER] ", tempTicker)
+
+			ex.chanSendMessage <- tempTicker
+		}
+	}
+
+	errHandler := func(err error) {
+		logger.Log.Error("Coinone subscribeTicker() return error : ", err)
+	}
+
+	// Serve
+	wsHandler := func(message []byte) {
+		event := new(CoinoneTickerEvent)
+		err := json.Unmarshal(message, event)
+
+		if err != nil {
+			errHandler(err)
+			return
+		}
+
+		switch event.ResponseType {
+		case "DATA":
+			if event.Channel == "TICKER" {
+				handler(&event.Data)
+			}
+		case "ERROR":
+			logger.Log.Error("Coinone error response : ", string(message))
+		}
+	}
+
+	// Keepalive response
+	protocol := func(message []byte, reply func(int, []byte) error) bool {
+		return coinoneIsPong(message)
+	}
+
+	cfg := &wsConfig{
+		endpoint:    ex.tickerEndpoint,
+		subsMessage: ex.subsMessage,
+		resync: func() {
+			ex.resyncSnapshot(func() { ex.initSnapshot(ex.snapshotCodes) })
+		},
+		protocol:          protocol,
+		keepaliveMessage:  []byte(`{"request_type":"PING"}`),
+		keepaliveInterval: coinoneKeepaliveInterval,
+	}
+
+	return websocketServe(ctx, ex.c, ex.reconnectLock, cfg, wsHandler, errHandler)
+}
+
+// coinoneIsPong returns true if message is keepalive response, ticker carrying "PONG" in data is not
+func coinoneIsPong(message []byte) bool {
+	var response struct {
+		ResponseType string `json:"response_type"`
+	}
+
+	if json.Unmarshal(message, &response) != nil {
+		return false
+	}
+
+	return response.ResponseType == "PONG"
+}
+
+func (ex *Coinone) Release() {
+	logger.Log.Info("[coinone.go] Start Release()")
+
+	if ex.c != nil {
+		ex.c.Close()
+	}
+
+	logger.Log.Info("[coinone.go] End Release()")
+}
+
+func (ex *Coinone) initSnapshot(targets []string) {
+	logger.Log.Info("[coinone.go] Start initSnapshot()")
+
+	data, err := restGet(coinoneSnapshotURL)
+	if err != nil {
+		logger.Log.Error("Coinone initSnapshot() failed : ", err)
+		return
+	}
+
+	// Parse JSON
+	var f struct {
+		Result  string               `json:"result"`
+		Tickers []CoinoneTickerField `json:"tickers"`
+	}
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		logger.Log.Error("Coinone initSnapshot() error parsing JSON: ", err)
+		return
+	}
+
+	subscribed := make(map[string]struct{}, len(targets))
+	for _, v := range targets {
+		subscribed[v] = struct{}{}
+	}
+
+	for i := range f.Tickers {
+		target := strings.ToUpper(f.Tickers[i].TargetCurrency)
+		if _, ok := subscribed[target]; !ok {
+			continue
+		}
+
+		ex.tickers[target] = ex.toTicker(&f.Tickers[i])
+	}
+
+	logger.Log.Info("[coinone.go] End initSnapshot()")
+}
+
+// -----
+func (ex *Coinone) toTicker(data *CoinoneTickerField) model.Ticker {
+	price := utils.ToFloat64(data.Last)
+	yesterday := utils.ToFloat64(data.YesterdayLast)
+
+	var changeRate float64
+	if yesterday != 0 {
+		changeRate = (price - yesterday) / yesterday
+	}
+
+	return model.Ticker{
+		Exchange:       "COINONE",
+		Currency:       normalizeSymbol(data.TargetCurrency),
+		Quote:          strings.ToUpper(data.QuoteCurrency),
+		Price:          price,
+		YesterdayPrice: yesterday,
+		Change:         price - yesterday,
+		ChangeRate:     changeRate,
+		Volume:         uint(utils.ToFloat64(data.QuoteVolume)),
+	}
+}
+
+func (ex *Coinone) getMarkets() ([]string, []string) {
+	var targets []string
+	var currencies []string
+
+	var info struct {
+		Result  string          `json:"result"`
+		Markets []CoinoneMarket `json:"markets"`
+	}
+
+	err := restGetJSON("Coinone getMarkets()", coinoneMarketURL, &info)
+	if err != nil {
+		logger.Log.Error("Coinone getMarkets() failed : ", err)
+		return nil, nil
+	}
+
+	for _, v := range info.Markets {
+		if v.TradeStatus != 1 {
+			continue
+		}
+
+		target := strings.ToUpper(v.TargetCurrency)
+		targets = append(targets, target)
+		currencies = append(currencies, normalizeSymbol(target))
+	}
+
+	return targets, currencies
+}
+
+func (ex *Coinone) makeSubsMessage(targets []string) []*[]byte {
+	// Subscribe message format, one market per message
+	// {"request_type":"SUBSCRIBE", "channel":"TICKER", "topic":{"quote_currency":"KRW", "target_currency":"BTC"}}
+	var msgs []*[]byte
+
+	for _, v := range targets {
+		msg, _ := json.Marshal(CoinoneSubsField{
+			RequestType: "SUBSCRIBE",
+			Channel:     "TICKER",
+			Topic: CoinoneTopicField{
+				QuoteCurrency:  "KRW",
+				TargetCurrency: v,
+			},
+		})
+		msgs = append(msgs, &msg)
+	}
+
+	return msgs
+}
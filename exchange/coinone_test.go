@@ -0,0 +1,19 @@
+package exchange
+
+import "testing"
+
+func Test_CoinoneIsPong(t *testing.T) {
+	cases := map[string]bool{
+		`{"response_type":"PONG"}`:                                   true,
+		`{"response_type":"DATA","data":{"target_currency":"PONG"}}`: false,
+		`{"response_type":"SUBSCRIBED","channel":"TICKER"}`:          false,
+		`"PONG"`: false,
+		`PONG`:   false,
+	}
+
+	for in, expected := range cases {
+		if out := coinoneIsPong([]byte(in)); out != expected {
+			t.Errorf("coinoneIsPong(%s) = %t, expected %t", in, out, expected)
+		}
+	}
+}
@@ -1,17 +1,30 @@
 package exchange
 
 import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 
+	"github.com/jeongpope/go-crix/logger"
 	"github.com/jeongpope/go-crix/model"
+	"github.com/jeongpope/go-crix/utils"
 )
 
 const (
 	signature_key = "SIGNATURE_JEONGPOPE"
 )
 
+// symbolAliases maps exchange specific currency codes onto upbit symbols
+var symbolAliases = map[string]string{
+	"XBT": "BTC",
+	"XDG": "DOGE",
+}
+
 // Handler handle raw websocket message
 type Handler func(msg []byte)
 
@@ -20,27 +33,187 @@ type ErrHandler func(err error)
 
 // Composition
 type exchange struct {
-	tickerEndpoint  string            // endpoint
-	c               *websocket.Conn   // websocket connection
-	reconnectLock   *sync.Mutex       // for single reconnect
-	subsMessage     []*[]byte         // subscribe request message
-	chanSendMessage chan model.Ticker // to send message channel
+	tickerEndpoint  string                 // endpoint
+	c               *websocket.Conn        // websocket connection
+	reconnectLock   *sync.Mutex            // for single reconnect
+	subsMessage     []*[]byte              // subscribe request message
+	chanSendMessage chan model.Ticker      // to send message channel
+	chanOrderBook   chan model.OrderBook   // to send orderbook channel
+	chanTrade       chan model.Trade       // to send trade channel
+	chanMarket      chan model.MarketEvent // to send market listing/delisting channel
 
 	supportAssets []string                // supported assets (uppercase)
 	tickers       map[string]model.Ticker // availiable tickers
 	updateLock    *sync.Mutex             // concurrent read/write
+
+	orderbooks map[string]model.OrderBook // latest orderbooks by market code
+
+	snapshotCodes []string // subscribed codes, snapshot is resynced after reconnect
 }
 
 // Polymorphism
 type IExchange interface {
-	Initialize(currencies *[]string) []string // Initialize
-	Execute() (err error)                     // Execute subscribe
-	Release()                                 // Release memory
+	Initialize(currencies *[]string) []string        // Initialize
+	Execute(ctx context.Context) (err error)         // Execute subscribe until ctx is cancelled
+	Release()                                        // Release memory
+	AttatchChannel(ch chan model.Ticker)             // Attatch ticker send channel
+	AttatchOrderBookChannel(ch chan model.OrderBook) // Attatch orderbook send channel
+	AttatchTradeChannel(ch chan model.Trade)         // Attatch trade send channel
+	AttatchMarketChannel(ch chan model.MarketEvent)  // Attatch market event send channel
 
 	initSnapshot([]string)
 }
 
-//
+// ISubscriber is implemented by exchanges which change subscribed markets at runtime
+type ISubscriber interface {
+	Subscribe(codes []string) error   // Subscribe market codes
+	Unsubscribe(codes []string) error // Unsubscribe market codes
+	SupportAssets() []string          // Assets of subscribed market codes
+}
+
 func (ex *exchange) AttatchChannel(ch chan model.Ticker) {
 	ex.chanSendMessage = ch
 }
+
+func (ex *exchange) AttatchOrderBookChannel(ch chan model.OrderBook) {
+	ex.chanOrderBook = ch
+}
+
+func (ex *exchange) AttatchTradeChannel(ch chan model.Trade) {
+	ex.chanTrade = ch
+}
+
+func (ex *exchange) AttatchMarketChannel(ch chan model.MarketEvent) {
+	ex.chanMarket = ch
+}
+
+// resyncSnapshot run snapshot after reconnect and send tickers whose price changed while disconnected,
+// called by websocket serve go-routine so snapshot does not race with handler
+func (ex *exchange) resyncSnapshot(snapshot func()) {
+	before := make(map[string]float64, len(ex.tickers))
+	for k, v := range ex.tickers {
+		before[k] = v.Price
+	}
+
+	snapshot()
+
+	for k, v := range ex.tickers {
+		if price, ok := before[k]; ok && price == v.Price {
+			continue
+		}
+
+		logger.Log.Info("[RESYNC] ", v)
+
+		ex.chanSendMessage <- v
+	}
+}
+
+// sendOrderBook send orderbook if orderbook channel attatched
+func (ex *exchange) sendOrderBook(ob model.OrderBook) {
+	if ex.chanOrderBook != nil {
+		ex.chanOrderBook <- ob
+	}
+}
+
+// sendTrade send trade if trade channel attatched
+func (ex *exchange) sendTrade(trade model.Trade) {
+	if ex.chanTrade != nil {
+		ex.chanTrade <- trade
+	}
+}
+
+// sendMarketEvent send market event if market channel attatched
+func (ex *exchange) sendMarketEvent(event model.MarketEvent) {
+	if ex.chanMarket != nil {
+		ex.chanMarket <- event
+	}
+}
+
+// normalizeSymbol returns currency code in upbit symbol (uppercase, alias resolved)
+func normalizeSymbol(code string) string {
+	code = strings.ToUpper(code)
+	if alias, ok := symbolAliases[code]; ok {
+		return alias
+	}
+
+	return code
+}
+
+// containsString returns true if values contains v
+func containsString(values []string, v string) bool {
+	for _, value := range values {
+		if value == v {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitEnv returns comma separated environment values, defaultValue if not set
+func splitEnv(key string, defaultValue string, upper bool) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		value = defaultValue
+	}
+
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+
+		if upper {
+			v = strings.ToUpper(v)
+		}
+		values = append(values, v)
+	}
+
+	return values
+}
+
+// chunkStrings split values into groups of size, size 0 returns one group, empty values returns no group
+func chunkStrings(values []string, size int) [][]string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	if size <= 0 || len(values) <= size {
+		return [][]string{values}
+	}
+
+	var chunks [][]string
+	for i := 0; i < len(values); i += size {
+		end := i + size
+		if end > len(values) {
+			end = len(values)
+		}
+
+		chunks = append(chunks, values[i:end])
+	}
+
+	return chunks
+}
+
+// restGet request GET by shared REST client and returns response body
+func restGet(url string) ([]byte, error) {
+	return defaultRESTClient.get(url)
+}
+
+// restGetJSON request GET and unmarshal response body into v,
+// failed request is retried by utils.DefaultRetryPolicy
+func restGetJSON(name string, url string, v interface{}) error {
+	policy := utils.DefaultRetryPolicy().WithOnRetry(func(attempt int, delay time.Duration, err error) {
+		logger.Log.Errorf("%s attempt %d return err, retry after %s : %s", name, attempt, delay, err.Error())
+	})
+
+	return policy.Retry(func() error {
+		data, err := restGet(url)
+		if err != nil {
+			return err
+		}
+
+		return json.Unmarshal(data, v)
+	})
+}
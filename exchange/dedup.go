@@ -0,0 +1,44 @@
+package exchange
+
+import (
+	"sync"
+)
+
+// dedupSet remembers recently seen keys, the oldest key is evicted over capacity
+type dedupSet struct {
+	capacity int
+	keys     []string
+	next     int
+	seen     map[string]struct{}
+	lock     *sync.Mutex
+}
+
+func newDedupSet(capacity int) *dedupSet {
+	return &dedupSet{
+		capacity: capacity,
+		keys:     make([]string, 0, capacity),
+		seen:     make(map[string]struct{}, capacity),
+		lock:     &sync.Mutex{},
+	}
+}
+
+// add returns false if key is already seen
+func (d *dedupSet) add(key string) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if _, ok := d.seen[key]; ok {
+		return false
+	}
+
+	if len(d.keys) < d.capacity {
+		d.keys = append(d.keys, key)
+	} else {
+		delete(d.seen, d.keys[d.next])
+		d.keys[d.next] = key
+		d.next = (d.next + 1) % d.capacity
+	}
+	d.seen[key] = struct{}{}
+
+	return true
+}
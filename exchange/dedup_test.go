@@ -0,0 +1,27 @@
+package exchange
+
+import (
+	"testing"
+)
+
+func Test_DedupSet(t *testing.T) {
+	d := newDedupSet(2)
+
+	if !d.add("KRW-BTC:1") || !d.add("KRW-BTC:2") {
+		t.Fatal("new key must be added")
+	}
+
+	if d.add("KRW-BTC:1") {
+		t.Fatal("seen key must be rejected")
+	}
+
+	// Evict KRW-BTC:1
+	d.add("KRW-BTC:3")
+	if !d.add("KRW-BTC:1") {
+		t.Fatal("evicted key must be added again")
+	}
+
+	if d.add("KRW-BTC:3") {
+		t.Fatal("seen key must be rejected")
+	}
+}
@@ -1,22 +1,48 @@
 package exchange
 
 import (
+	"context"
 	"errors"
+	"sync"
 
 	"github.com/jeongpope/go-crix/goredis"
 	"github.com/jeongpope/go-crix/logger"
+	"github.com/jeongpope/go-crix/model"
 )
 
 var instance *stCrix
 
 var (
 	ErrFailedGetRequest = errors.New("retry request after 10 minute")
+	ErrNotRunning       = errors.New("exchange is not running")
+	ErrNotSupported     = errors.New("exchange does not support runtime subscription")
 )
 
 type stCrix struct {
-	upbit *Upbit
+	names     []string             // running exchange names, in configuration order
+	exchanges map[string]IExchange // running exchanges by name
 
+	assets       map[string][]string // assets by exchange name
 	supportAsset []string
+
+	runLock *sync.Mutex
+	cancels map[string]context.CancelFunc // cancel of running Execute() by name
+	wg      *sync.WaitGroup
+
+	chanTicker chan model.Ticker   // tickers of every exchange, fanned out to redis and listeners
+	listeners  []chan model.Ticker // ticker listeners (ex. index)
+
+	chanMarket      chan model.MarketEvent   // market events of every exchange, fanned out to redis and listeners
+	marketListeners []chan model.MarketEvent // market event listeners (ex. index)
+
+	chanOrderBook chan model.OrderBook // orderbooks of every exchange, forwarded to redis
+	chanTrade     chan model.Trade     // trades of every exchange, forwarded to redis
+
+	// Sinks of fanout (redis)
+	sinkTicker    chan model.Ticker
+	sinkOrderBook chan model.OrderBook
+	sinkTrade     chan model.Trade
+	sinkMarket    chan model.MarketEvent
 }
 
 func GetInstance() *stCrix {
@@ -46,29 +72,233 @@ func (i *stCrix) initialize() error {
 func (i *stCrix) initExchange() {
 	logger.Log.Info("[exchange.go] Start initExchange()")
 
-	i.upbit = new(Upbit)
-	i.supportAsset = i.upbit.Initialize(nil)
-	i.upbit.AttatchChannel(goredis.GetInstance().GetTickerChannel())
+	i.exchanges = make(map[string]IExchange)
+	i.assets = make(map[string][]string)
+	i.runLock = &sync.Mutex{}
+	i.cancels = make(map[string]context.CancelFunc)
+	i.wg = &sync.WaitGroup{}
+	i.chanTicker = make(chan model.Ticker, 512)
+	i.chanMarket = make(chan model.MarketEvent, 64)
+	i.chanOrderBook = make(chan model.OrderBook, 512)
+	i.chanTrade = make(chan model.Trade, 512)
+
+	i.sinkTicker = goredis.GetInstance().GetTickerChannel()
+	i.sinkOrderBook = goredis.GetInstance().GetOrderBookChannel()
+	i.sinkTrade = goredis.GetInstance().GetTradeChannel()
+	i.sinkMarket = goredis.GetInstance().GetMarketChannel()
+
+	for _, name := range enabledExchanges() {
+		ex := newExchange(name)
+
+		assets := ex.Initialize(nil)
+		ex.AttatchChannel(i.chanTicker)
+		ex.AttatchOrderBookChannel(i.chanOrderBook)
+		ex.AttatchTradeChannel(i.chanTrade)
+		ex.AttatchMarketChannel(i.chanMarket)
+
+		i.names = append(i.names, name)
+		i.exchanges[name] = ex
+		i.assets[name] = assets
+		i.supportAsset = mergeAssets(i.supportAsset, assets)
+
+		logger.Log.Infof("[exchange.go] %s initialized, %d assets", name, len(assets))
+	}
 
 	logger.Log.Info("[exchange.go] End initExchange()")
 }
 
-func (i *stCrix) Update() {
-	stopC := make(chan struct{})
-
+// Update execute every exchange and blocks until ctx is cancelled and all exchanges return
+func (i *stCrix) Update(ctx context.Context) {
 	logger.Log.Info("[exchange.go] Start Update()")
 
-	go i.upbit.Execute()
+	// Fanout keeps draining after cancel, handler blocked on send returns and exchange stops
+	drained := make(chan struct{})
+	go i.fanout(ctx, drained)
+
+	for _, name := range i.names {
+		i.start(ctx, name)
+	}
+
+	<-ctx.Done()
+	i.wg.Wait()
+	close(drained)
 
 	logger.Log.Info("[exchange.go] End Update()")
+}
+
+// Listen returns channel receiving tickers of every exchange, must be called before Update()
+func (i *stCrix) Listen() <-chan model.Ticker {
+	ch := make(chan model.Ticker, 512)
+	i.listeners = append(i.listeners, ch)
 
-	<-stopC
+	return ch
 }
 
+// ListenMarket returns channel receiving market events of every exchange, must be called before Update()
+func (i *stCrix) ListenMarket() <-chan model.MarketEvent {
+	ch := make(chan model.MarketEvent, 64)
+	i.marketListeners = append(i.marketListeners, ch)
+
+	return ch
+}
+
+// fanout forward tickers, orderbooks, trades and market events to redis and listeners until drained is closed,
+// after ctx is cancelled messages are dropped instead of blocking on sink
+func (i *stCrix) fanout(ctx context.Context, drained <-chan struct{}) {
+	for {
+		select {
+		case <-drained:
+			return
+		case ticker := <-i.chanTicker:
+			select {
+			case i.sinkTicker <- ticker:
+			case <-ctx.Done():
+			}
+
+			for _, ch := range i.listeners {
+				select {
+				case ch <- ticker:
+				case <-ctx.Done():
+				}
+			}
+		case ob := <-i.chanOrderBook:
+			select {
+			case i.sinkOrderBook <- ob:
+			case <-ctx.Done():
+			}
+		case trade := <-i.chanTrade:
+			select {
+			case i.sinkTrade <- trade:
+			case <-ctx.Done():
+			}
+		case event := <-i.chanMarket:
+			// Listing and delisting change subscription of exchange (ex. upbit market refresh)
+			i.refreshAssets(event.Exchange)
+
+			select {
+			case i.sinkMarket <- event:
+			case <-ctx.Done():
+			}
+
+			for _, ch := range i.marketListeners {
+				select {
+				case ch <- event:
+				case <-ctx.Done():
+				}
+			}
+		}
+	}
+}
+
+func (i *stCrix) start(ctx context.Context, name string) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	i.runLock.Lock()
+	i.cancels[name] = cancel
+	i.runLock.Unlock()
+
+	i.wg.Add(1)
+	go func(ex IExchange) {
+		defer i.wg.Done()
+		defer i.Stop(name)
+
+		err := ex.Execute(ctx)
+		if err != nil {
+			logger.Log.Errorf("[exchange.go] %s Execute() return error : %s", name, err.Error())
+		}
+
+		logger.Log.Infof("[exchange.go] %s stopped", name)
+	}(i.exchanges[name])
+}
+
+// Stop cancel Execute() of exchange, other exchanges keep running
+func (i *stCrix) Stop(name string) error {
+	i.runLock.Lock()
+	cancel, ok := i.cancels[name]
+	delete(i.cancels, name)
+	i.runLock.Unlock()
+
+	if !ok {
+		return ErrNotRunning
+	}
+
+	cancel()
+	return nil
+}
+
+// Release stop every exchange, wait until Execute() returns and release exchanges
 func (i *stCrix) Release() {
 	logger.Log.Info("[exchange.go] Start Release()")
 
-	i.upbit.Release()
+	for _, name := range i.names {
+		i.Stop(name)
+	}
+	i.wg.Wait()
+
+	for _, name := range i.names {
+		i.exchanges[name].Release()
+	}
 
 	logger.Log.Info("[exchange.go] End Release()")
 }
+
+// Subscribe add market codes to running exchange, ErrNotSupported if exchange does not implement ISubscriber (upbit only)
+func (i *stCrix) Subscribe(name string, codes []string) error {
+	return i.changeSubscription(name, func(sub ISubscriber) error { return sub.Subscribe(codes) })
+}
+
+// Unsubscribe remove market codes from running exchange, ErrNotSupported if exchange does not implement ISubscriber (upbit only)
+func (i *stCrix) Unsubscribe(name string, codes []string) error {
+	return i.changeSubscription(name, func(sub ISubscriber) error { return sub.Unsubscribe(codes) })
+}
+
+func (i *stCrix) changeSubscription(name string, change func(sub ISubscriber) error) error {
+	ex, ok := i.exchanges[name]
+	if !ok {
+		return ErrNotRunning
+	}
+
+	sub, ok := ex.(ISubscriber)
+	if !ok {
+		return ErrNotSupported
+	}
+
+	err := change(sub)
+	if err != nil {
+		return err
+	}
+
+	i.refreshAssets(name)
+	return nil
+}
+
+// refreshAssets reload assets of exchange supporting runtime subscription and merge support assets
+func (i *stCrix) refreshAssets(name string) {
+	sub, ok := i.exchanges[name].(ISubscriber)
+	if !ok {
+		return
+	}
+
+	i.runLock.Lock()
+	defer i.runLock.Unlock()
+
+	i.assets[name] = sub.SupportAssets()
+
+	i.supportAsset = nil
+	for _, v := range i.names {
+		i.supportAsset = mergeAssets(i.supportAsset, i.assets[v])
+	}
+}
+
+// GetSupportAssets returns merged assets of running exchanges
+func (i *stCrix) GetSupportAssets() []string {
+	i.runLock.Lock()
+	defer i.runLock.Unlock()
+
+	return i.supportAsset
+}
+
+// GetWebsocketStats returns websocket connection event counts and mode by connection, served by GET /status
+func (i *stCrix) GetWebsocketStats() map[string]WebsocketStat {
+	return websocketStats()
+}
@@ -0,0 +1,57 @@
+package exchange
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jeongpope/go-crix/model"
+)
+
+// blockingExchange sends tickers without watching ctx like websocket handler
+type blockingExchange struct {
+	exchange
+}
+
+func (ex *blockingExchange) Initialize(currencies *[]string) []string { return nil }
+func (ex *blockingExchange) Release()                                 {}
+func (ex *blockingExchange) initSnapshot([]string)                    {}
+
+func (ex *blockingExchange) Execute(ctx context.Context) error {
+	for ctx.Err() == nil {
+		ex.chanSendMessage <- model.Ticker{Exchange: "BLOCKING", Price: 1}
+	}
+
+	return nil
+}
+
+func Test_UpdateReturnsWhileSinkBlocked(t *testing.T) {
+	ex := new(blockingExchange)
+	i := &stCrix{
+		names:      []string{"BLOCKING"},
+		exchanges:  map[string]IExchange{"BLOCKING": ex},
+		runLock:    &sync.Mutex{},
+		cancels:    make(map[string]context.CancelFunc),
+		wg:         &sync.WaitGroup{},
+		chanTicker: make(chan model.Ticker, 1),
+		sinkTicker: make(chan model.Ticker), // nobody receives, ex. redis is down
+	}
+	ex.AttatchChannel(i.chanTicker)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		i.Update(ctx)
+		close(done)
+	}()
+
+	time.Sleep(time.Millisecond * 50)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second * 2):
+		t.Fatal("Update() did not return after cancel while sink is blocked")
+	}
+}
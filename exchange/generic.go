@@ -0,0 +1,381 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeongpope/go-crix/logger"
+	"github.com/jeongpope/go-crix/model"
+)
+
+const (
+	genericCodesPlaceholder = "{{codes}}" // replaced with JSON array of market codes
+)
+
+var genericOnce sync.Once
+
+// Generic is exchange adapter driven by GenericDefinition,
+// for venues which differ only in endpoint, subscribe payload and field names
+type Generic struct {
+	exchange
+
+	def     GenericDefinition
+	markets map[string]genericMarket // market code : market information
+}
+
+type genericMarket struct {
+	base  string
+	quote string
+}
+
+// GenericDefinition define generic exchange configuration
+//
+// GOCRIX_GENERIC_CONFIG : JSON file path, array of GenericDefinition (see generic.example.json)
+// Paths are dot separated object keys or array indexes (ex. data.0.price), empty path is root
+type GenericDefinition struct {
+	Name              string `json:"name"`               // exchange name (ex. GOPAX)
+	Endpoint          string `json:"endpoint"`           // websocket endpoint
+	Subscribe         string `json:"subscribe"`          // subscribe message template, {{codes}} is replaced with JSON array of codes
+	KeepaliveMessage  string `json:"keepalive_message"`  // optional, text message sent periodically
+	KeepaliveInterval int    `json:"keepalive_interval"` // keepalive interval (second)
+
+	Markets  GenericMarkets  `json:"markets"`  // market list REST request
+	Ticker   GenericFields   `json:"ticker"`   // websocket ticker message fields
+	Snapshot GenericSnapshot `json:"snapshot"` // optional, snapshot REST request
+}
+
+// GenericMarkets define market list REST request and fields
+type GenericMarkets struct {
+	URL        string `json:"url"`         // market list REST url
+	ListPath   string `json:"list_path"`   // path to market array
+	CodePath   string `json:"code_path"`   // path to market code in element (ex. BTC-KRW)
+	BasePath   string `json:"base_path"`   // path to base asset in element
+	QuotePath  string `json:"quote_path"`  // path to quote asset in element
+	Separator  string `json:"separator"`   // optional, split code into assets if base_path is empty (ex. -)
+	QuoteFirst bool   `json:"quote_first"` // code is quote first (ex. KRW-BTC)
+	Quote      string `json:"quote"`       // optional, subscribe only this quote asset
+}
+
+// GenericSnapshot define snapshot REST request and fields
+type GenericSnapshot struct {
+	URL      string        `json:"url"`       // snapshot REST url
+	ListPath string        `json:"list_path"` // path to ticker array
+	Fields   GenericFields `json:"fields"`    // ticker fields in element
+}
+
+// GenericFields define JSON paths mapped onto model.Ticker
+type GenericFields struct {
+	MatchPath         string `json:"match_path"`           // optional, message is ticker if value at path equals match_value
+	MatchValue        string `json:"match_value"`          // match value (ex. ticker)
+	CodePath          string `json:"code_path"`            // market code
+	PricePath         string `json:"price_path"`           // model.Ticker.Price
+	YesterdayPath     string `json:"yesterday_price_path"` // model.Ticker.YesterdayPrice
+	ChangePath        string `json:"change_path"`          // model.Ticker.Change
+	ChangeRatePath    string `json:"change_rate_path"`     // model.Ticker.ChangeRate
+	ChangeRatePercent bool   `json:"change_rate_percent"`  // change rate is percent (1.5 means 1.5%)
+	VolumePath        string `json:"volume_path"`          // model.Ticker.Volume, quote volume
+}
+
+// registerGenerics register exchanges of GOCRIX_GENERIC_CONFIG once,
+// invalid definition and name of registered exchange are skipped with error
+func registerGenerics() {
+	genericOnce.Do(func() {
+		path := os.Getenv("GOCRIX_GENERIC_CONFIG")
+		if path == "" {
+			return
+		}
+
+		defs, err := loadGenericDefinitions(path)
+		if err != nil {
+			logger.Log.Errorf("Failed to load generic exchange config %s, %s", path, err.Error())
+			return
+		}
+
+		for _, def := range defs {
+			if def.Name == "" || def.Endpoint == "" || def.Markets.URL == "" {
+				logger.Log.Errorf("Invalid generic exchange %q, name, endpoint and markets.url are required, skip", def.Name)
+				continue
+			}
+
+			// Ticker carries registry name, which is upper case as built-in exchanges
+			d := def
+			d.Name = strings.ToUpper(d.Name)
+			if !register(d.Name, func() IExchange { return &Generic{def: d} }) {
+				logger.Log.Errorf("Generic exchange %s is already registered, skip", d.Name)
+			}
+		}
+	})
+}
+
+func loadGenericDefinitions(path string) ([]GenericDefinition, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []GenericDefinition
+	err = json.Unmarshal(data, &defs)
+	if err != nil {
+		return nil, err
+	}
+
+	return defs, nil
+}
+
+func (ex *Generic) Initialize(currencies *[]string) []string {
+	logger.Log.Infof("[generic.go] %s Start Initialize()", ex.def.Name)
+
+	codes, coins := ex.getMarkets()
+	msg := ex.makeSubsMessage(codes)
+
+	ex.tickerEndpoint = ex.def.Endpoint
+	ex.c = nil
+	ex.reconnectLock = &sync.Mutex{}
+	ex.subsMessage = append(ex.subsMessage, &msg)
+	ex.chanSendMessage = nil
+
+	ex.supportAssets = append(ex.supportAssets, coins...)
+	ex.tickers = make(map[string]model.Ticker)
+	ex.updateLock = nil
+
+	ex.snapshotCodes = codes
+	ex.initSnapshot(codes)
+
+	logger.Log.Infof("[generic.go] %s End Initialize()", ex.def.Name)
+
+	return ex.supportAssets
+}
+
+func (ex *Generic) Execute(ctx context.Context) (err error) {
+	logger.Log.Infof("[generic.go] %s Start Execute()", ex.def.Name)
+
+	errHandler := func(err error) {
+		logger.Log.Errorf("%s subscribeTicker() return error : %s", ex.def.Name, err.Error())
+	}
+
+	// Serve
+	wsHandler := func(message []byte) {
+		var event interface{}
+		err := json.Unmarshal(message, &event)
+
+		if err != nil {
+			errHandler(err)
+			return
+		}
+
+		tempTicker, code, ok := ex.toTicker(event, &ex.def.Ticker)
+		if !ok {
+			return
+		}
+
+		if ex.tickers[code].Price != tempTicker.Price {
+			ex.tickers[code] = tempTicker
+			logger.Log.Info("[TICKER] ", tempTicker)
+
+			ex.chanSendMessage <- tempTicker
+		}
+	}
+
+	cfg := &wsConfig{
+		endpoint:    ex.tickerEndpoint,
+		subsMessage: ex.subsMessage,
+		resync: func() {
+			ex.resyncSnapshot(func() { ex.initSnapshot(ex.snapshotCodes) })
+		},
+	}
+
+	if ex.def.KeepaliveMessage != "" && ex.def.KeepaliveInterval > 0 {
+		cfg.keepaliveMessage = []byte(ex.def.KeepaliveMessage)
+		cfg.keepaliveInterval = time.Second * time.Duration(ex.def.KeepaliveInterval)
+	}
+
+	return websocketServe(ctx, ex.c, ex.reconnectLock, cfg, wsHandler, errHandler)
+}
+
+func (ex *Generic) Release() {
+	logger.Log.Infof("[generic.go] %s Start Release()", ex.def.Name)
+
+	if ex.c != nil {
+		ex.c.Close()
+	}
+
+	logger.Log.Infof("[generic.go] %s End Release()", ex.def.Name)
+}
+
+func (ex *Generic) initSnapshot(codes []string) {
+	if ex.def.Snapshot.URL == "" {
+		return
+	}
+
+	logger.Log.Infof("[generic.go] %s Start initSnapshot()", ex.def.Name)
+
+	data, err := restGet(ex.def.Snapshot.URL)
+	if err != nil {
+		logger.Log.Errorf("%s initSnapshot() failed : %s", ex.def.Name, err.Error())
+		return
+	}
+
+	var f interface{}
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		logger.Log.Errorf("%s initSnapshot() error parsing JSON: %s", ex.def.Name, err.Error())
+		return
+	}
+
+	list, _ := genericPath(f, ex.def.Snapshot.ListPath).([]interface{})
+	for _, v := range list {
+		tempTicker, code, ok := ex.toTicker(v, &ex.def.Snapshot.Fields)
+		if ok {
+			ex.tickers[code] = tempTicker
+		}
+	}
+
+	logger.Log.Infof("[generic.go] %s End initSnapshot()", ex.def.Name)
+}
+
+// -----
+// toTicker map event onto ticker, returns false if event is not ticker of subscribed market
+func (ex *Generic) toTicker(event interface{}, fields *GenericFields) (model.Ticker, string, bool) {
+	if fields.MatchPath != "" && genericString(genericPath(event, fields.MatchPath)) != fields.MatchValue {
+		return model.Ticker{}, "", false
+	}
+
+	code := genericString(genericPath(event, fields.CodePath))
+	market, ok := ex.markets[code]
+	if !ok {
+		return model.Ticker{}, "", false
+	}
+
+	changeRate := genericFloat(genericPath(event, fields.ChangeRatePath))
+	if fields.ChangeRatePercent {
+		changeRate /= 100
+	}
+
+	return model.Ticker{
+		Exchange:       ex.def.Name,
+		Currency:       market.base,
+		Quote:          market.quote,
+		Price:          genericFloat(genericPath(event, fields.PricePath)),
+		YesterdayPrice: genericFloat(genericPath(event, fields.YesterdayPath)),
+		Change:         genericFloat(genericPath(event, fields.ChangePath)),
+		ChangeRate:     changeRate,
+		Volume:         uint(genericFloat(genericPath(event, fields.VolumePath))),
+	}, code, true
+}
+
+func (ex *Generic) getMarkets() ([]string, []string) {
+	var codes []string
+	var currencies []string
+
+	var f interface{}
+
+	err := restGetJSON(ex.def.Name+" getMarkets()", ex.def.Markets.URL, &f)
+	if err != nil {
+		logger.Log.Errorf("%s getMarkets() failed : %s", ex.def.Name, err.Error())
+		return nil, nil
+	}
+
+	ex.markets = make(map[string]genericMarket)
+
+	list, _ := genericPath(f, ex.def.Markets.ListPath).([]interface{})
+	for _, v := range list {
+		code := genericString(genericPath(v, ex.def.Markets.CodePath))
+		base, quote := ex.splitCode(v, code)
+
+		if code == "" || base == "" {
+			continue
+		}
+
+		if ex.def.Markets.Quote != "" && quote != normalizeSymbol(ex.def.Markets.Quote) {
+			continue
+		}
+
+		ex.markets[code] = genericMarket{base: base, quote: quote}
+		codes = append(codes, code)
+		currencies = mergeAssets(currencies, []string{base})
+	}
+
+	return codes, currencies
+}
+
+// splitCode returns base and quote asset of market element
+func (ex *Generic) splitCode(v interface{}, code string) (base string, quote string) {
+	m := &ex.def.Markets
+
+	if m.BasePath == "" && m.Separator != "" {
+		parts := strings.SplitN(code, m.Separator, 2)
+		if len(parts) != 2 {
+			return "", ""
+		}
+
+		if m.QuoteFirst {
+			parts[0], parts[1] = parts[1], parts[0]
+		}
+
+		return normalizeSymbol(parts[0]), normalizeSymbol(parts[1])
+	}
+
+	return normalizeSymbol(genericString(genericPath(v, m.BasePath))),
+		normalizeSymbol(genericString(genericPath(v, m.QuotePath)))
+}
+
+func (ex *Generic) makeSubsMessage(codes []string) []byte {
+	// Subscribe message format is defined by template
+	// ex) {"type":"ticker", "codes":{{codes}}}
+	codesJSON, _ := json.Marshal(codes)
+
+	return []byte(strings.Replace(ex.def.Subscribe, genericCodesPlaceholder, string(codesJSON), -1))
+}
+
+// genericPath returns value at dot separated path, nil if not exists
+func genericPath(v interface{}, path string) interface{} {
+	if path == "" {
+		return v
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			v = node[key]
+		case []interface{}:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(node) {
+				return nil
+			}
+			v = node[idx]
+		default:
+			return nil
+		}
+	}
+
+	return v
+}
+
+func genericString(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+func genericFloat(v interface{}) float64 {
+	switch value := v.(type) {
+	case float64:
+		return value
+	case string:
+		f, _ := strconv.ParseFloat(value, 64)
+		return f
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,46 @@
+package exchange
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func Test_RegisterGenerics(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "generic.json")
+	ioutil.WriteFile(path, []byte(`[
+		{"name": "gopax", "endpoint": "wss://example.com", "markets": {"url": "https://example.com"}},
+		{"name": "GOPAX", "endpoint": "wss://example.com", "markets": {"url": "https://example.com"}},
+		{"name": "UPBIT", "endpoint": "wss://example.com", "markets": {"url": "https://example.com"}},
+		{"name": "NOENDPOINT", "markets": {"url": "https://example.com"}}
+	]`), 0644)
+
+	os.Setenv("GOCRIX_GENERIC_CONFIG", path)
+	defer os.Unsetenv("GOCRIX_GENERIC_CONFIG")
+	genericOnce = sync.Once{}
+	defer delete(registry, "GOPAX")
+
+	registerGenerics()
+
+	ex, ok := newExchange("GOPAX").(*Generic)
+	if !ok {
+		t.Fatal("expected GOPAX registered as generic exchange")
+	}
+
+	// Ticker of lower case definition carries upper case registry name
+	ex.markets = map[string]genericMarket{"BTC-KRW": {base: "BTC", quote: "KRW"}}
+	ticker, _, ok := ex.toTicker(map[string]interface{}{"code": "BTC-KRW"}, &GenericFields{CodePath: "code"})
+	if !ok || ticker.Exchange != "GOPAX" {
+		t.Errorf("expected ticker of GOPAX, got %q", ticker.Exchange)
+	}
+
+	if _, ok := newExchange("UPBIT").(*Upbit); !ok {
+		t.Error("expected built-in UPBIT kept")
+	}
+
+	if newExchange("NOENDPOINT") != nil {
+		t.Error("expected definition without endpoint rejected")
+	}
+}
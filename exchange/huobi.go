@@ -0,0 +1,281 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jeongpope/go-crix/logger"
+	"github.com/jeongpope/go-crix/model"
+	"github.com/jeongpope/go-crix/utils"
+)
+
+const (
+	huobiQuoteAsset = "usdt" // collected quote asset (lowercase)
+)
+
+type Huobi struct {
+	exchange
+
+	markets map[string]HuobiSymbol // symbol (ex. btcusdt) : symbol information
+}
+
+func init() {
+	register("HUOBI", func() IExchange { return new(Huobi) })
+}
+
+// HuobiTickerEvent define websocket market ticker event
+type HuobiTickerEvent struct {
+	Ch   string           `json:"ch"`   // 채널 (ex. market.btcusdt.ticker)
+	Ts   int64            `json:"ts"`   // 타임스탬프 (milliseconds)
+	Tick HuobiTickerField `json:"tick"` // 현재가 정보
+}
+
+// HuobiTickerField define huobi 24h rolling ticker
+type HuobiTickerField struct {
+	Symbol    string  `json:"symbol"`    // 심볼, REST 응답에만 포함 (ex. btcusdt)
+	Open      float64 `json:"open"`      // 24시간 시가
+	High      float64 `json:"high"`      // 24시간 고가
+	Low       float64 `json:"low"`       // 24시간 저가
+	Close     float64 `json:"close"`     // 현재가
+	Amount    float64 `json:"amount"`    // 24시간 누적 거래량 (base currency)
+	Vol       float64 `json:"vol"`       // 24시간 누적 거래대금 (quote currency)
+	Count     int64   `json:"count"`     // 24시간 거래 수
+	Bid       float64 `json:"bid"`       // 최우선 매수 호가
+	BidSize   float64 `json:"bidSize"`   // 최우선 매수 잔량
+	Ask       float64 `json:"ask"`       // 최우선 매도 호가
+	AskSize   float64 `json:"askSize"`   // 최우선 매도 잔량
+	LastPrice float64 `json:"lastPrice"` // 최근 체결가
+	LastSize  float64 `json:"lastSize"`  // 최근 체결량
+}
+
+// HuobiSubsField define huobi websocket subscribe request
+type HuobiSubsField struct {
+	Sub string `json:"sub"`
+	ID  string `json:"id"`
+}
+
+// HuobiSymbol define huobi symbol information
+type HuobiSymbol struct {
+	Symbol        string `json:"symbol"`         // 심볼 (ex. btcusdt)
+	BaseCurrency  string `json:"base-currency"`  // 기준 자산 (ex. btc)
+	QuoteCurrency string `json:"quote-currency"` // 호가 자산 (ex. usdt)
+	State         string `json:"state"`          // 상태 (online)
+}
+
+func (ex *Huobi) Initialize(currencies *[]string) []string {
+	logger.Log.Info("[huobi.go] Start Initialize()")
+
+	symbols, coins := ex.getMarkets()
+
+	ex.tickerEndpoint = huobiTickerURL
+	ex.c = nil
+	ex.reconnectLock = &sync.Mutex{}
+	ex.subsMessage = ex.makeSubsMessage(symbols)
+	ex.chanSendMessage = nil
+
+	ex.supportAssets = append(ex.supportAssets, coins...)
+	ex.tickers = make(map[string]model.Ticker)
+	ex.updateLock = nil
+
+	ex.snapshotCodes = symbols
+	ex.initSnapshot(symbols)
+
+	logger.Log.Info("[huobi.go] End Initialize()")
+
+	return ex.supportAssets
+}
+
+func (ex *Huobi) Execute(ctx context.Context) (err error) {
+	logger.Log.Info("[huobi.go] Start Execute()")
+
+	handler := func(symbol string, tick *HuobiTickerField) {
+		market, ok := ex.markets[symbol]
+		if !ok {
+			return
+		}
+
+		if ex.tickers[symbol].Price != tick.Close {
+			tempTicker := ex.toTicker(market, tick)
+
+			ex.tickers[symbol] = tempTicker
+			logger.Log.Info("[TICKER] ", tempTicker)
+
+			ex.chanSendMessage <- tempTicker
+		}
+	}
+
+	errHandler := func(err error) {
+		logger.Log.Error("Huobi subscribeTicker() return error : ", err)
+	}
+
+	// Serve
+	wsHandler := func(message []byte) {
+		event := new(HuobiTickerEvent)
+		err := json.Unmarshal(message, event)
+
+		if err != nil {
+			errHandler(err)
+			return
+		}
+
+		// Skip subscribe responses ({"id":"btcusdt","status":"ok","subbed":"..."})
+		if event.Ch == "" {
+			return
+		}
+
+		// market.$symbol.ticker
+		parts := strings.Split(event.Ch, ".")
+		if len(parts) != 3 {
+			return
+		}
+
+		handler(parts[1], &event.Tick)
+	}
+
+	// Every frame is gzip compressed
+	decoder := func(messageType int, message []byte) ([]byte, error) {
+		return utils.DecompressGzip(message)
+	}
+
+	// Server sends {"ping":n} and drops connection without {"pong":n}
+	protocol := func(message []byte, reply func(int, []byte) error) bool {
+		var ping struct {
+			Ping int64 `json:"ping"`
+		}
+
+		if json.Unmarshal(message, &ping) != nil || ping.Ping == 0 {
+			return false
+		}
+
+		pong, _ := json.Marshal(map[string]int64{"pong": ping.Ping})
+		err := reply(websocket.TextMessage, pong)
+		if err != nil {
+			logger.Log.Error("Huobi pong return error : ", err)
+		}
+
+		return true
+	}
+
+	cfg := &wsConfig{
+		endpoint:    ex.tickerEndpoint,
+		subsMessage: ex.subsMessage,
+		resync: func() {
+			ex.resyncSnapshot(func() { ex.initSnapshot(ex.snapshotCodes) })
+		},
+		decoder:  decoder,
+		protocol: protocol,
+	}
+
+	return websocketServe(ctx, ex.c, ex.reconnectLock, cfg, wsHandler, errHandler)
+}
+
+func (ex *Huobi) Release() {
+	logger.Log.Info("[huobi.go] Start Release()")
+
+	if ex.c != nil {
+		ex.c.Close()
+	}
+
+	logger.Log.Info("[huobi.go] End Release()")
+}
+
+func (ex *Huobi) initSnapshot(symbols []string) {
+	logger.Log.Info("[huobi.go] Start initSnapshot()")
+
+	data, err := restGet(huobiSnapshotURL)
+	if err != nil {
+		logger.Log.Error("Huobi initSnapshot() failed : ", err)
+		return
+	}
+
+	// Parse JSON
+	var f struct {
+		Status string             `json:"status"`
+		Data   []HuobiTickerField `json:"data"`
+	}
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		logger.Log.Error("Huobi initSnapshot() error parsing JSON: ", err)
+		return
+	}
+
+	for i := range f.Data {
+		market, ok := ex.markets[f.Data[i].Symbol]
+		if !ok {
+			continue
+		}
+
+		ex.tickers[market.Symbol] = ex.toTicker(market, &f.Data[i])
+	}
+
+	logger.Log.Info("[huobi.go] End initSnapshot()")
+}
+
+// -----
+func (ex *Huobi) toTicker(market HuobiSymbol, tick *HuobiTickerField) model.Ticker {
+	// Huobi provides 24h rolling open price only
+	var changeRate float64
+	if tick.Open != 0 {
+		changeRate = (tick.Close - tick.Open) / tick.Open
+	}
+
+	return model.Ticker{
+		Exchange:       "HUOBI",
+		Currency:       strings.ToUpper(market.BaseCurrency),
+		Quote:          strings.ToUpper(market.QuoteCurrency),
+		Price:          tick.Close,
+		YesterdayPrice: tick.Open,
+		Change:         tick.Close - tick.Open,
+		ChangeRate:     changeRate,
+		Volume:         uint(tick.Vol),
+	}
+}
+
+func (ex *Huobi) getMarkets() ([]string, []string) {
+	var symbols []string
+	var currencies []string
+
+	var info struct {
+		Status string        `json:"status"`
+		Data   []HuobiSymbol `json:"data"`
+	}
+
+	err := restGetJSON("Huobi getMarkets()", huobiMarketURL, &info)
+	if err != nil {
+		logger.Log.Error("Huobi getMarkets() failed : ", err)
+		return nil, nil
+	}
+
+	ex.markets = make(map[string]HuobiSymbol)
+	for _, v := range info.Data {
+		if v.State != "online" || v.QuoteCurrency != huobiQuoteAsset {
+			continue
+		}
+
+		ex.markets[v.Symbol] = v
+		symbols = append(symbols, v.Symbol)
+		currencies = append(currencies, strings.ToUpper(v.BaseCurrency))
+	}
+
+	return symbols, currencies
+}
+
+func (ex *Huobi) makeSubsMessage(symbols []string) []*[]byte {
+	// Subscribe message format, one topic per message
+	// {"sub":"market.btcusdt.ticker", "id":"btcusdt"}
+	var msgs []*[]byte
+
+	for _, v := range symbols {
+		msg, _ := json.Marshal(HuobiSubsField{
+			Sub: "market." + v + ".ticker",
+			ID:  v,
+		})
+		msgs = append(msgs, &msg)
+	}
+
+	return msgs
+}
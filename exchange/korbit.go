@@ -0,0 +1,225 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/jeongpope/go-crix/logger"
+	"github.com/jeongpope/go-crix/model"
+	"github.com/jeongpope/go-crix/utils"
+)
+
+type Korbit struct {
+	exchange
+}
+
+func init() {
+	register("KORBIT", func() IExchange { return new(Korbit) })
+}
+
+// KorbitTickerEvent define websocket ticker event
+type KorbitTickerEvent struct {
+	Type      string            `json:"type"`      // 타입(ticker : 현재가)
+	Symbol    string            `json:"symbol"`    // 통화 코드 (ex. btc_krw)
+	Timestamp int64             `json:"timestamp"` // 타임스탬프 (milliseconds)
+	Snapshot  bool              `json:"snapshot"`  // 스냅샷 여부
+	Data      KorbitTickerField `json:"data"`      // 현재가 정보
+}
+
+// KorbitTickerField define korbit 24h ticker
+type KorbitTickerField struct {
+	Symbol             string `json:"symbol"`             // 통화 코드, REST 응답에만 포함 (ex. btc_krw)
+	Open               string `json:"open"`               // 24시간 시가
+	High               string `json:"high"`               // 24시간 고가
+	Low                string `json:"low"`                // 24시간 저가
+	Close              string `json:"close"`              // 현재가
+	PrevClose          string `json:"prevClose"`          // 전일 종가
+	PriceChange        string `json:"priceChange"`        // 전일 대비 값
+	PriceChangePercent string `json:"priceChangePercent"` // 전일 대비 등락율 (%)
+	Volume             string `json:"volume"`             // 24시간 누적 거래량
+	QuoteVolume        string `json:"quoteVolume"`        // 24시간 누적 거래대금
+	BestBidPrice       string `json:"bestBidPrice"`       // 최우선 매수 호가
+	BestAskPrice       string `json:"bestAskPrice"`       // 최우선 매도 호가
+	LastTradedAt       int64  `json:"lastTradedAt"`       // 최근 거래 시각 (milliseconds)
+}
+
+// KorbitSubsField define korbit websocket subscribe request
+type KorbitSubsField struct {
+	Method  string   `json:"method"`
+	Type    string   `json:"type"`
+	Symbols []string `json:"symbols"`
+}
+
+// KorbitMarket define korbit currency pair information
+type KorbitMarket struct {
+	Symbol string `json:"symbol"` // 통화 코드 (ex. btc_krw)
+	Status string `json:"status"` // 상태 (launched)
+}
+
+func (ex *Korbit) Initialize(currencies *[]string) []string {
+	logger.Log.Info("[korbit.go] Start Initialize()")
+
+	symbols, coins := ex.getMarkets()
+	msg := ex.makeSubsMessage(symbols)
+
+	ex.tickerEndpoint = korbitTickerURL
+	ex.c = nil
+	ex.reconnectLock = &sync.Mutex{}
+	ex.subsMessage = append(ex.subsMessage, &msg)
+	ex.chanSendMessage = nil
+
+	ex.supportAssets = append(ex.supportAssets, coins...)
+	ex.tickers = make(map[string]model.Ticker)
+	ex.updateLock = nil
+
+	ex.snapshotCodes = symbols
+	ex.initSnapshot(symbols)
+
+	logger.Log.Info("[korbit.go] End Initialize()")
+
+	return ex.supportAssets
+}
+
+func (ex *Korbit) Execute(ctx context.Context) (err error) {
+	logger.Log.Info("[korbit.go] Start Execute()")
+
+	handler := func(symbol string, data *KorbitTickerField) {
+		price := utils.ToFloat64(data.Close)
+
+		if ex.tickers[symbol].Price != price {
+			tempTicker := ex.toTicker(symbol, data)
+
+			ex.tickers[symbol] = tempTicker
+			logger.Log.Info("[TICKER] ", tempTicker)
+
+			ex.chanSendMessage <- tempTicker
+		}
+	}
+
+	errHandler := func(err error) {
+		logger.Log.Error("Korbit subscribeTicker() return error : ", err)
+	}
+
+	// Serve
+	wsHandler := func(message []byte) {
+		event := new(KorbitTickerEvent)
+		err := json.Unmarshal(message, event)
+
+		if err != nil {
+			errHandler(err)
+			return
+		}
+
+		// Skip subscribe responses
+		if event.Type != "ticker" {
+			return
+		}
+
+		handler(event.Symbol, &event.Data)
+	}
+
+	cfg := &wsConfig{
+		endpoint:    ex.tickerEndpoint,
+		subsMessage: ex.subsMessage,
+		resync: func() {
+			ex.resyncSnapshot(func() { ex.initSnapshot(ex.snapshotCodes) })
+		},
+	}
+
+	return websocketServe(ctx, ex.c, ex.reconnectLock, cfg, wsHandler, errHandler)
+}
+
+func (ex *Korbit) Release() {
+	logger.Log.Info("[korbit.go] Start Release()")
+
+	if ex.c != nil {
+		ex.c.Close()
+	}
+
+	logger.Log.Info("[korbit.go] End Release()")
+}
+
+func (ex *Korbit) initSnapshot(symbols []string) {
+	logger.Log.Info("[korbit.go] Start initSnapshot()")
+
+	data, err := restGet(korbitSnapshotURL + "?symbol=" + strings.Join(symbols, ","))
+	if err != nil {
+		logger.Log.Error("Korbit initSnapshot() failed : ", err)
+		return
+	}
+
+	// Parse JSON
+	var f struct {
+		Success bool                `json:"success"`
+		Data    []KorbitTickerField `json:"data"`
+	}
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		logger.Log.Error("Korbit initSnapshot() error parsing JSON: ", err)
+		return
+	}
+
+	for i := range f.Data {
+		ex.tickers[f.Data[i].Symbol] = ex.toTicker(f.Data[i].Symbol, &f.Data[i])
+	}
+
+	logger.Log.Info("[korbit.go] End initSnapshot()")
+}
+
+// -----
+func (ex *Korbit) toTicker(symbol string, data *KorbitTickerField) model.Ticker {
+	return model.Ticker{
+		Exchange:       "KORBIT",
+		Currency:       normalizeSymbol(strings.TrimSuffix(symbol, "_krw")),
+		Quote:          "KRW",
+		Price:          utils.ToFloat64(data.Close),
+		YesterdayPrice: utils.ToFloat64(data.PrevClose),
+		Change:         utils.ToFloat64(data.PriceChange),
+		ChangeRate:     utils.ToFloat64(data.PriceChangePercent) / 100,
+		Volume:         uint(utils.ToFloat64(data.QuoteVolume)),
+	}
+}
+
+func (ex *Korbit) getMarkets() ([]string, []string) {
+	var symbols []string
+	var currencies []string
+
+	var info struct {
+		Success bool           `json:"success"`
+		Data    []KorbitMarket `json:"data"`
+	}
+
+	err := restGetJSON("Korbit getMarkets()", korbitMarketURL, &info)
+	if err != nil {
+		logger.Log.Error("Korbit getMarkets() failed : ", err)
+		return nil, nil
+	}
+
+	for _, v := range info.Data {
+		if v.Status != "launched" || !strings.HasSuffix(v.Symbol, "_krw") {
+			continue
+		}
+
+		symbols = append(symbols, v.Symbol)
+		currencies = append(currencies, normalizeSymbol(strings.TrimSuffix(v.Symbol, "_krw")))
+	}
+
+	return symbols, currencies
+}
+
+func (ex *Korbit) makeSubsMessage(symbols []string) []byte {
+	// Subscribe message format
+	// [{"method":"subscribe", "type":"ticker", "symbols":["btc_krw"]}]
+	msg := []KorbitSubsField{
+		{
+			Method:  "subscribe",
+			Type:    "ticker",
+			Symbols: symbols,
+		},
+	}
+	tickerMsg, _ := json.Marshal(msg)
+
+	return tickerMsg
+}
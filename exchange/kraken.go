@@ -0,0 +1,540 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jeongpope/go-crix/logger"
+	"github.com/jeongpope/go-crix/model"
+	"github.com/jeongpope/go-crix/utils"
+)
+
+const (
+	krakenBookDepth     = 10 // subscribed book depth
+	krakenChecksumDepth = 10 // levels per side used in checksum
+	krakenSubsChunk     = 50 // pairs per subscribe message
+)
+
+var (
+	krakenQuoteAssets = []string{"USD", "EUR"} // collected quote assets
+)
+
+type Kraken struct {
+	exchange
+
+	pairs         map[string]KrakenPair  // ws name (ex. XBT/USD) : pair information
+	books         map[string]*krakenBook // ws name : maintained book
+	resubscribing map[string]bool        // ws name : book resubscribe requested
+}
+
+func init() {
+	register("KRAKEN", func() IExchange { return new(Kraken) })
+}
+
+// KrakenEvent define websocket event object (heartbeat, systemStatus, subscriptionStatus)
+type KrakenEvent struct {
+	Event        string `json:"event"`        // 이벤트
+	Status       string `json:"status"`       // 상태
+	Pair         string `json:"pair"`         // 페어 (ex. XBT/USD)
+	ErrorMessage string `json:"errorMessage"` // 에러 메시지
+}
+
+// KrakenTickerField define kraken ticker payload, REST "o" is string and websocket "o" is array
+type KrakenTickerField struct {
+	A []string        `json:"a"` // 최우선 매도 호가 [price, whole lot volume, lot volume]
+	B []string        `json:"b"` // 최우선 매수 호가 [price, whole lot volume, lot volume]
+	C []string        `json:"c"` // 최근 체결 [price, lot volume]
+	V []string        `json:"v"` // 거래량 [today, last 24 hours]
+	P []string        `json:"p"` // 가중 평균가 [today, last 24 hours]
+	L []string        `json:"l"` // 저가 [today, last 24 hours]
+	H []string        `json:"h"` // 고가 [today, last 24 hours]
+	O json.RawMessage `json:"o"` // 시가 today or [today, last 24 hours]
+}
+
+// KrakenBookField define kraken book payload, snapshot has as/bs and update has a/b/c
+type KrakenBookField struct {
+	As       [][]string `json:"as"` // 매도 호가 스냅샷 [price, volume, timestamp]
+	Bs       [][]string `json:"bs"` // 매수 호가 스냅샷 [price, volume, timestamp]
+	A        [][]string `json:"a"`  // 매도 호가 업데이트 [price, volume, timestamp, (r)]
+	B        [][]string `json:"b"`  // 매수 호가 업데이트 [price, volume, timestamp, (r)]
+	Checksum string     `json:"c"`  // CRC32 체크섬
+}
+
+// KrakenSubsField define kraken websocket subscribe request
+type KrakenSubsField struct {
+	Event        string                  `json:"event"`
+	Pair         []string                `json:"pair"`
+	Subscription KrakenSubscriptionField `json:"subscription"`
+}
+
+// KrakenSubscriptionField define kraken websocket subscription
+type KrakenSubscriptionField struct {
+	Name  string `json:"name"`
+	Depth int    `json:"depth,omitempty"`
+}
+
+// KrakenPair define kraken asset pair information
+type KrakenPair struct {
+	Key     string `json:"-"`       // REST 페어 이름 (ex. XXBTZUSD)
+	Altname string `json:"altname"` // 대체 이름 (ex. XBTUSD)
+	WsName  string `json:"wsname"`  // 웹소켓 페어 이름 (ex. XBT/USD)
+	Status  string `json:"status"`  // 상태 (online)
+}
+
+// krakenLevel keeps original strings, checksum is calculated from them
+type krakenLevel struct {
+	price  string
+	volume string
+	p      float64
+}
+
+type krakenBook struct {
+	asks []krakenLevel // ascending by price
+	bids []krakenLevel // descending by price
+}
+
+func (ex *Kraken) Initialize(currencies *[]string) []string {
+	logger.Log.Info("[kraken.go] Start Initialize()")
+
+	wsNames, coins := ex.getMarkets()
+
+	ex.tickerEndpoint = krakenTickerURL
+	ex.c = nil
+	ex.reconnectLock = &sync.Mutex{}
+	ex.subsMessage = ex.makeSubsMessage(wsNames)
+	ex.chanSendMessage = nil
+
+	ex.supportAssets = append(ex.supportAssets, coins...)
+	ex.tickers = make(map[string]model.Ticker)
+	ex.updateLock = nil
+
+	ex.orderbooks = make(map[string]model.OrderBook)
+	ex.books = make(map[string]*krakenBook)
+	ex.resubscribing = make(map[string]bool)
+
+	ex.snapshotCodes = wsNames
+	ex.initSnapshot(wsNames)
+
+	logger.Log.Info("[kraken.go] End Initialize()")
+
+	return ex.supportAssets
+}
+
+func (ex *Kraken) Execute(ctx context.Context) (err error) {
+	logger.Log.Info("[kraken.go] Start Execute()")
+
+	cfg := &wsConfig{
+		endpoint:    ex.tickerEndpoint,
+		subsMessage: ex.subsMessage,
+		resync: func() {
+			ex.resyncSnapshot(func() { ex.initSnapshot(ex.snapshotCodes) })
+		},
+	}
+
+	handler := func(wsName string, field *KrakenTickerField) {
+		if len(field.C) == 0 {
+			return
+		}
+
+		price := utils.ToFloat64(field.C[0])
+
+		if ex.tickers[wsName].Price != price {
+			tempTicker := ex.toTicker(wsName, field)
+
+			ex.tickers[wsName] = tempTicker
+			logger.Log.Info("[TICKER] ", tempTicker)
+
+			ex.chanSendMessage <- tempTicker
+		}
+	}
+
+	bookHandler := func(wsName string, fields []*KrakenBookField) {
+		book, ok := ex.books[wsName]
+
+		for _, field := range fields {
+			if len(field.As) > 0 || len(field.Bs) > 0 {
+				book = &krakenBook{}
+				book.asks = applyKrakenLevels(nil, field.As, true)
+				book.bids = applyKrakenLevels(nil, field.Bs, false)
+
+				ex.books[wsName] = book
+				ex.resubscribing[wsName] = false
+				ok = true
+				continue
+			}
+
+			// Update before snapshot, wait for snapshot
+			if !ok {
+				return
+			}
+
+			book.asks = applyKrakenLevels(book.asks, field.A, true)
+			book.bids = applyKrakenLevels(book.bids, field.B, false)
+
+			if field.Checksum != "" && field.Checksum != strconv.FormatUint(uint64(book.checksum()), 10) {
+				logger.Log.Errorf("Kraken %s book checksum mismatch, resubscribe", wsName)
+
+				delete(ex.books, wsName)
+				ex.resubscribeBook(cfg, wsName)
+				return
+			}
+		}
+
+		if !ok {
+			return
+		}
+
+		ob := ex.toOrderBook(wsName, book)
+		ex.orderbooks[wsName] = ob
+
+		ex.sendOrderBook(ob)
+	}
+
+	errHandler := func(err error) {
+		logger.Log.Error("Kraken subscribeTicker() return error : ", err)
+	}
+
+	// Serve
+	wsHandler := func(message []byte) {
+		// Event object
+		if len(message) > 0 && message[0] == '{' {
+			event := new(KrakenEvent)
+			err := json.Unmarshal(message, event)
+			if err != nil {
+				errHandler(err)
+				return
+			}
+
+			if event.Status == "error" {
+				logger.Log.Errorf("Kraken %s %s error : %s", event.Event, event.Pair, event.ErrorMessage)
+			}
+			return
+		}
+
+		// Channel message [channelID, payload..., channelName, pair]
+		var fields []json.RawMessage
+		err := json.Unmarshal(message, &fields)
+		if err != nil {
+			errHandler(err)
+			return
+		}
+
+		if len(fields) < 4 {
+			return
+		}
+
+		var channel, wsName string
+		json.Unmarshal(fields[len(fields)-2], &channel)
+		json.Unmarshal(fields[len(fields)-1], &wsName)
+		payloads := fields[1 : len(fields)-2]
+
+		switch {
+		case channel == "ticker":
+			field := new(KrakenTickerField)
+			if err := json.Unmarshal(payloads[0], field); err != nil {
+				errHandler(err)
+				return
+			}
+
+			handler(wsName, field)
+		case strings.HasPrefix(channel, "book"):
+			var books []*KrakenBookField
+			for _, payload := range payloads {
+				field := new(KrakenBookField)
+				if err := json.Unmarshal(payload, field); err != nil {
+					errHandler(err)
+					return
+				}
+				books = append(books, field)
+			}
+
+			bookHandler(wsName, books)
+		}
+	}
+
+	return websocketServe(ctx, ex.c, ex.reconnectLock, cfg, wsHandler, errHandler)
+}
+
+func (ex *Kraken) Release() {
+	logger.Log.Info("[kraken.go] Start Release()")
+
+	if ex.c != nil {
+		ex.c.Close()
+	}
+
+	logger.Log.Info("[kraken.go] End Release()")
+}
+
+func (ex *Kraken) initSnapshot(wsNames []string) {
+	logger.Log.Info("[kraken.go] Start initSnapshot()")
+
+	data, err := restGet(krakenSnapshotURL)
+	if err != nil {
+		logger.Log.Error("Kraken initSnapshot() failed : ", err)
+		return
+	}
+
+	// Parse JSON, result is keyed by REST pair name
+	var f struct {
+		Error  []string                     `json:"error"`
+		Result map[string]KrakenTickerField `json:"result"`
+	}
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		logger.Log.Error("Kraken initSnapshot() error parsing JSON: ", err)
+		return
+	}
+
+	for _, wsName := range wsNames {
+		field, ok := f.Result[ex.pairs[wsName].Key]
+		if !ok || len(field.C) == 0 {
+			continue
+		}
+
+		ex.tickers[wsName] = ex.toTicker(wsName, &field)
+	}
+
+	logger.Log.Info("[kraken.go] End initSnapshot()")
+}
+
+// -----
+// resubscribeBook request fresh book snapshot of pair
+func (ex *Kraken) resubscribeBook(cfg *wsConfig, wsName string) {
+	if ex.resubscribing[wsName] {
+		return
+	}
+	ex.resubscribing[wsName] = true
+
+	subscription := KrakenSubscriptionField{Name: "book", Depth: krakenBookDepth}
+
+	unsubscribe, _ := json.Marshal(KrakenSubsField{
+		Event:        "unsubscribe",
+		Pair:         []string{wsName},
+		Subscription: subscription,
+	})
+	subscribe, _ := json.Marshal(KrakenSubsField{
+		Event:        "subscribe",
+		Pair:         []string{wsName},
+		Subscription: subscription,
+	})
+
+	for _, msg := range [][]byte{unsubscribe, subscribe} {
+		err := cfg.send(websocket.TextMessage, msg)
+		if err != nil {
+			logger.Log.Errorf("Kraken %s book resubscribe failed : %s", wsName, err.Error())
+		}
+	}
+}
+
+func (ex *Kraken) toTicker(wsName string, field *KrakenTickerField) model.Ticker {
+	base, quote := splitKrakenWsName(wsName)
+	price := utils.ToFloat64(field.C[0])
+
+	// Today open (UTC 0), websocket sends [today, last 24 hours]
+	var open float64
+	var opens []string
+	if json.Unmarshal(field.O, &opens) == nil && len(opens) > 0 {
+		open = utils.ToFloat64(opens[0])
+	} else {
+		var o string
+		json.Unmarshal(field.O, &o)
+		open = utils.ToFloat64(o)
+	}
+
+	var changeRate float64
+	if open != 0 {
+		changeRate = (price - open) / open
+	}
+
+	// 24h quote volume = 24h volume * 24h vwap
+	var volume float64
+	if len(field.V) > 1 && len(field.P) > 1 {
+		volume = utils.ToFloat64(field.V[1]) * utils.ToFloat64(field.P[1])
+	}
+
+	return model.Ticker{
+		Exchange:       "KRAKEN",
+		Currency:       base,
+		Quote:          quote,
+		Price:          price,
+		YesterdayPrice: open,
+		Change:         price - open,
+		ChangeRate:     changeRate,
+		Volume:         uint(volume),
+	}
+}
+
+func (ex *Kraken) toOrderBook(wsName string, book *krakenBook) model.OrderBook {
+	base, quote := splitKrakenWsName(wsName)
+
+	ob := model.OrderBook{
+		Exchange:  "KRAKEN",
+		Currency:  base,
+		Quote:     quote,
+		Asks:      make([]model.OrderBookUnit, 0, len(book.asks)),
+		Bids:      make([]model.OrderBookUnit, 0, len(book.bids)),
+		Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+	}
+
+	for _, v := range book.asks {
+		size := utils.ToFloat64(v.volume)
+		ob.Asks = append(ob.Asks, model.OrderBookUnit{Price: v.p, Size: size})
+		ob.TotalAskSize += size
+	}
+
+	for _, v := range book.bids {
+		size := utils.ToFloat64(v.volume)
+		ob.Bids = append(ob.Bids, model.OrderBookUnit{Price: v.p, Size: size})
+		ob.TotalBidSize += size
+	}
+
+	return ob
+}
+
+func (ex *Kraken) getMarkets() ([]string, []string) {
+	var wsNames []string
+	var currencies []string
+
+	var info struct {
+		Error  []string              `json:"error"`
+		Result map[string]KrakenPair `json:"result"`
+	}
+
+	err := restGetJSON("Kraken getMarkets()", krakenMarketURL, &info)
+	if err != nil {
+		logger.Log.Error("Kraken getMarkets() failed : ", err)
+		return nil, nil
+	}
+
+	ex.pairs = make(map[string]KrakenPair)
+	for key, v := range info.Result {
+		if v.Status != "online" || v.WsName == "" {
+			continue
+		}
+
+		base, quote := splitKrakenWsName(v.WsName)
+		if !containsString(krakenQuoteAssets, quote) {
+			continue
+		}
+
+		v.Key = key
+		ex.pairs[v.WsName] = v
+		wsNames = append(wsNames, v.WsName)
+		currencies = mergeAssets(currencies, []string{base})
+	}
+
+	sort.Strings(wsNames)
+
+	return wsNames, currencies
+}
+
+func (ex *Kraken) makeSubsMessage(wsNames []string) []*[]byte {
+	// Subscribe message format, ticker and book per krakenSubsChunk pairs
+	// {"event":"subscribe", "pair":["XBT/USD"], "subscription":{"name":"ticker"}}
+	var msgs []*[]byte
+
+	for i := 0; i < len(wsNames); i += krakenSubsChunk {
+		end := i + krakenSubsChunk
+		if end > len(wsNames) {
+			end = len(wsNames)
+		}
+
+		for _, subscription := range []KrakenSubscriptionField{
+			{Name: "ticker"},
+			{Name: "book", Depth: krakenBookDepth},
+		} {
+			msg, _ := json.Marshal(KrakenSubsField{
+				Event:        "subscribe",
+				Pair:         wsNames[i:end],
+				Subscription: subscription,
+			})
+			msgs = append(msgs, &msg)
+		}
+	}
+
+	return msgs
+}
+
+// checksum returns CRC32 of top asks (ascending) and bids (descending)
+func (b *krakenBook) checksum() uint32 {
+	var sb strings.Builder
+
+	for i := 0; i < len(b.asks) && i < krakenChecksumDepth; i++ {
+		sb.WriteString(krakenChecksumField(b.asks[i].price))
+		sb.WriteString(krakenChecksumField(b.asks[i].volume))
+	}
+
+	for i := 0; i < len(b.bids) && i < krakenChecksumDepth; i++ {
+		sb.WriteString(krakenChecksumField(b.bids[i].price))
+		sb.WriteString(krakenChecksumField(b.bids[i].volume))
+	}
+
+	return crc32.ChecksumIEEE([]byte(sb.String()))
+}
+
+// krakenChecksumField remove decimal point and leading zeros
+// ex) 0.05005 : 5005, 0.00000500 : 500
+func krakenChecksumField(v string) string {
+	return strings.TrimLeft(strings.Replace(v, ".", "", 1), "0")
+}
+
+// applyKrakenLevels apply level updates, zero volume removes level
+func applyKrakenLevels(levels []krakenLevel, updates [][]string, ascending bool) []krakenLevel {
+	for _, u := range updates {
+		if len(u) < 2 {
+			continue
+		}
+
+		level := krakenLevel{price: u[0], volume: u[1], p: utils.ToFloat64(u[0])}
+		remove := utils.ToFloat64(level.volume) == 0
+
+		idx := sort.Search(len(levels), func(i int) bool {
+			if ascending {
+				return levels[i].p >= level.p
+			}
+			return levels[i].p <= level.p
+		})
+
+		if idx < len(levels) && levels[idx].p == level.p {
+			if remove {
+				levels = append(levels[:idx], levels[idx+1:]...)
+			} else {
+				levels[idx] = level
+			}
+			continue
+		}
+
+		if remove {
+			continue
+		}
+
+		levels = append(levels, krakenLevel{})
+		copy(levels[idx+1:], levels[idx:])
+		levels[idx] = level
+	}
+
+	if len(levels) > krakenBookDepth {
+		levels = levels[:krakenBookDepth]
+	}
+
+	return levels
+}
+
+// splitKrakenWsName split kraken ws name into base and quote asset
+// ex) XBT/USD : BTC, USD
+func splitKrakenWsName(wsName string) (base string, quote string) {
+	idx := strings.Index(wsName, "/")
+	if idx < 0 {
+		return normalizeSymbol(wsName), ""
+	}
+
+	return normalizeSymbol(wsName[:idx]), normalizeSymbol(wsName[idx+1:])
+}
@@ -0,0 +1,56 @@
+package exchange
+
+import (
+	"hash/crc32"
+	"testing"
+)
+
+func Test_KrakenChecksumField(t *testing.T) {
+	cases := map[string]string{
+		"0.05005":    "5005",
+		"0.00000500": "500",
+		"5541.30000": "554130000",
+		"1.00000000": "100000000",
+	}
+
+	for in, expected := range cases {
+		if out := krakenChecksumField(in); out != expected {
+			t.Errorf("krakenChecksumField(%s) = %s, expected %s", in, out, expected)
+		}
+	}
+}
+
+func Test_KrakenBook(t *testing.T) {
+	book := &krakenBook{}
+	book.asks = applyKrakenLevels(nil, [][]string{
+		{"5541.30000", "2.50700000", "1534614248.123678"},
+		{"5541.80000", "0.33000000", "1534614098.345543"},
+	}, true)
+	book.bids = applyKrakenLevels(nil, [][]string{
+		{"5541.20000", "1.52900000", "1534614248.765567"},
+		{"5539.90000", "0.30000000", "1534614241.769870"},
+	}, false)
+
+	// Insert, replace and remove
+	book.asks = applyKrakenLevels(book.asks, [][]string{
+		{"5541.50000", "1.00000000", "1534614249.000000"},
+		{"5541.80000", "0.00000000", "1534614249.000000"},
+	}, true)
+	book.bids = applyKrakenLevels(book.bids, [][]string{
+		{"5541.20000", "1.00000000", "1534614249.000000", "r"},
+	}, false)
+
+	if len(book.asks) != 2 || book.asks[0].price != "5541.30000" || book.asks[1].price != "5541.50000" {
+		t.Fatalf("unexpected asks %v", book.asks)
+	}
+
+	if len(book.bids) != 2 || book.bids[0].volume != "1.00000000" || book.bids[1].price != "5539.90000" {
+		t.Fatalf("unexpected bids %v", book.bids)
+	}
+
+	expected := crc32.ChecksumIEEE([]byte("554130000250700000" + "554150000100000000" +
+		"554120000100000000" + "55399000030000000"))
+	if book.checksum() != expected {
+		t.Errorf("checksum %d, expected %d", book.checksum(), expected)
+	}
+}
@@ -0,0 +1,305 @@
+package exchange
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jeongpope/go-crix/logger"
+	"github.com/jeongpope/go-crix/model"
+	"github.com/jeongpope/go-crix/utils"
+)
+
+const (
+	okxQuoteAsset        = "USDT"           // collected quote asset
+	okxSubsChunk         = 100              // instruments per subscribe message
+	okxKeepaliveInterval = time.Second * 20 // server closes connection idle for 30 second
+)
+
+type OKX struct {
+	exchange
+}
+
+func init() {
+	register("OKX", func() IExchange { return new(OKX) })
+}
+
+// OKXPushEvent define websocket push data
+type OKXPushEvent struct {
+	Event string           `json:"event"` // 이벤트 (subscribe, error), push 데이터는 빈 값
+	Code  string           `json:"code"`  // 에러 코드
+	Msg   string           `json:"msg"`   // 에러 메시지
+	Arg   OKXArgField      `json:"arg"`   // 구독 채널
+	Data  []OKXTickerEvent `json:"data"`  // 데이터
+}
+
+// OKXTickerEvent define websocket tickers channel data
+type OKXTickerEvent struct {
+	InstType  string `json:"instType"`  // 상품 타입 (SPOT)
+	InstID    string `json:"instId"`    // 상품 ID (ex. BTC-USDT)
+	Last      string `json:"last"`      // 현재가
+	LastSz    string `json:"lastSz"`    // 가장 최근 거래량
+	AskPx     string `json:"askPx"`     // 최우선 매도 호가
+	AskSz     string `json:"askSz"`     // 최우선 매도 잔량
+	BidPx     string `json:"bidPx"`     // 최우선 매수 호가
+	BidSz     string `json:"bidSz"`     // 최우선 매수 잔량
+	Open24h   string `json:"open24h"`   // 24시간 시가
+	High24h   string `json:"high24h"`   // 24시간 고가
+	Low24h    string `json:"low24h"`    // 24시간 저가
+	SodUtc0   string `json:"sodUtc0"`   // UTC 0시 시가
+	SodUtc8   string `json:"sodUtc8"`   // UTC+8 0시 시가
+	VolCcy24h string `json:"volCcy24h"` // 24시간 누적 거래대금 (quote currency)
+	Vol24h    string `json:"vol24h"`    // 24시간 누적 거래량 (base currency)
+	Ts        string `json:"ts"`        // 타임스탬프 (milliseconds)
+}
+
+// OKXArgField define okx websocket channel argument
+type OKXArgField struct {
+	Channel string `json:"channel"`
+	InstID  string `json:"instId"`
+}
+
+// OKXSubsField define okx websocket subscribe request
+type OKXSubsField struct {
+	Op   string        `json:"op"`
+	Args []OKXArgField `json:"args"`
+}
+
+// OKXInstrument define okx instrument information
+type OKXInstrument struct {
+	InstID   string `json:"instId"`   // 상품 ID (ex. BTC-USDT)
+	BaseCcy  string `json:"baseCcy"`  // 기준 자산 (ex. BTC)
+	QuoteCcy string `json:"quoteCcy"` // 호가 자산 (ex. USDT)
+	State    string `json:"state"`    // 상태 (live)
+}
+
+func (ex *OKX) Initialize(currencies *[]string) []string {
+	logger.Log.Info("[okx.go] Start Initialize()")
+
+	instIDs, coins := ex.getMarkets()
+
+	ex.tickerEndpoint = okxTickerURL
+	ex.c = nil
+	ex.reconnectLock = &sync.Mutex{}
+	ex.subsMessage = ex.makeSubsMessage(instIDs)
+	ex.chanSendMessage = nil
+
+	ex.supportAssets = append(ex.supportAssets, coins...)
+	ex.tickers = make(map[string]model.Ticker)
+	ex.updateLock = nil
+
+	ex.snapshotCodes = instIDs
+	ex.initSnapshot(instIDs)
+
+	logger.Log.Info("[okx.go] End Initialize()")
+
+	return ex.supportAssets
+}
+
+func (ex *OKX) Execute(ctx context.Context) (err error) {
+	logger.Log.Info("[okx.go] Start Execute()")
+
+	handler := func(event *OKXTickerEvent) {
+		price := utils.ToFloat64(event.Last)
+
+		if ex.tickers[event.InstID].Price != price {
+			tempTicker := ex.toTicker(event)
+
+			ex.tickers[event.InstID] = tempTicker
+			logger.Log.Info("[TICKER] ", tempTicker)
+
+			ex.chanSendMessage <- tempTicker
+		}
+	}
+
+	errHandler := func(err error) {
+		logger.Log.Error("OKX subscribeTicker() return error : ", err)
+	}
+
+	// Serve
+	wsHandler := func(message []byte) {
+		event := new(OKXPushEvent)
+		err := json.Unmarshal(message, event)
+
+		if err != nil {
+			errHandler(err)
+			return
+		}
+
+		if event.Event == "error" {
+			logger.Log.Errorf("OKX error response, code : %s, msg : %s", event.Code, event.Msg)
+			return
+		}
+
+		for i := range event.Data {
+			handler(&event.Data[i])
+		}
+	}
+
+	// OKX may send deflate compressed binary frames
+	decoder := func(messageType int, message []byte) ([]byte, error) {
+		if messageType == websocket.BinaryMessage {
+			return utils.DecompressFlate(message)
+		}
+
+		return message, nil
+	}
+
+	// Keepalive response
+	protocol := func(message []byte, reply func(int, []byte) error) bool {
+		return string(message) == "pong"
+	}
+
+	cfg := &wsConfig{
+		endpoint:    ex.tickerEndpoint,
+		subsMessage: ex.subsMessage,
+		resync: func() {
+			ex.resyncSnapshot(func() { ex.initSnapshot(ex.snapshotCodes) })
+		},
+		decoder:           decoder,
+		protocol:          protocol,
+		keepaliveMessage:  []byte("ping"),
+		keepaliveInterval: okxKeepaliveInterval,
+	}
+
+	return websocketServe(ctx, ex.c, ex.reconnectLock, cfg, wsHandler, errHandler)
+}
+
+func (ex *OKX) Release() {
+	logger.Log.Info("[okx.go] Start Release()")
+
+	if ex.c != nil {
+		ex.c.Close()
+	}
+
+	logger.Log.Info("[okx.go] End Release()")
+}
+
+func (ex *OKX) initSnapshot(instIDs []string) {
+	logger.Log.Info("[okx.go] Start initSnapshot()")
+
+	data, err := restGet(okxSnapshotURL)
+	if err != nil {
+		logger.Log.Error("OKX initSnapshot() failed : ", err)
+		return
+	}
+
+	// Parse JSON
+	var f struct {
+		Code string           `json:"code"`
+		Data []OKXTickerEvent `json:"data"`
+	}
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		logger.Log.Error("OKX initSnapshot() error parsing JSON: ", err)
+		return
+	}
+
+	subscribed := make(map[string]struct{}, len(instIDs))
+	for _, v := range instIDs {
+		subscribed[v] = struct{}{}
+	}
+
+	for i := range f.Data {
+		if _, ok := subscribed[f.Data[i].InstID]; !ok {
+			continue
+		}
+
+		ex.tickers[f.Data[i].InstID] = ex.toTicker(&f.Data[i])
+	}
+
+	logger.Log.Info("[okx.go] End initSnapshot()")
+}
+
+// -----
+func (ex *OKX) toTicker(event *OKXTickerEvent) model.Ticker {
+	base, quote := splitOKXInstID(event.InstID)
+	price := utils.ToFloat64(event.Last)
+	sod := utils.ToFloat64(event.SodUtc0)
+
+	// Change is calculated from UTC 0 open price, same as upbit
+	var changeRate float64
+	if sod != 0 {
+		changeRate = (price - sod) / sod
+	}
+
+	return model.Ticker{
+		Exchange:       "OKX",
+		Currency:       base,
+		Quote:          quote,
+		Price:          price,
+		YesterdayPrice: sod,
+		Change:         price - sod,
+		ChangeRate:     changeRate,
+		Volume:         uint(utils.ToFloat64(event.VolCcy24h)),
+	}
+}
+
+func (ex *OKX) getMarkets() ([]string, []string) {
+	var instIDs []string
+	var currencies []string
+
+	var info struct {
+		Code string          `json:"code"`
+		Data []OKXInstrument `json:"data"`
+	}
+
+	err := restGetJSON("OKX getMarkets()", okxMarketURL, &info)
+	if err != nil {
+		logger.Log.Error("OKX getMarkets() failed : ", err)
+		return nil, nil
+	}
+
+	for _, v := range info.Data {
+		base, quote := splitOKXInstID(v.InstID)
+		if v.State != "live" || quote != okxQuoteAsset {
+			continue
+		}
+
+		instIDs = append(instIDs, v.InstID)
+		currencies = append(currencies, base)
+	}
+
+	return instIDs, currencies
+}
+
+func (ex *OKX) makeSubsMessage(instIDs []string) []*[]byte {
+	// Subscribe message format, split by okxSubsChunk instruments
+	// {"op":"subscribe", "args":[{"channel":"tickers", "instId":"BTC-USDT"}]}
+	var msgs []*[]byte
+
+	for i := 0; i < len(instIDs); i += okxSubsChunk {
+		end := i + okxSubsChunk
+		if end > len(instIDs) {
+			end = len(instIDs)
+		}
+
+		var args []OKXArgField
+		for _, v := range instIDs[i:end] {
+			args = append(args, OKXArgField{Channel: "tickers", InstID: v})
+		}
+
+		msg, _ := json.Marshal(OKXSubsField{
+			Op:   "subscribe",
+			Args: args,
+		})
+		msgs = append(msgs, &msg)
+	}
+
+	return msgs
+}
+
+// splitOKXInstID split okx instrument id into base and quote asset
+// ex) BTC-USDT : BTC, USDT
+func splitOKXInstID(instID string) (base string, quote string) {
+	idx := strings.Index(instID, "-")
+	if idx < 0 {
+		return instID, ""
+	}
+
+	return instID[:idx], instID[idx+1:]
+}
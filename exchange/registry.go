@@ -0,0 +1,74 @@
+package exchange
+
+import (
+	"strings"
+
+	"github.com/jeongpope/go-crix/logger"
+)
+
+const (
+	defaultExchanges = "UPBIT"
+)
+
+// registry holds exchange constructors by exchange name (uppercase)
+var registry = map[string]func() IExchange{}
+
+// register adds exchange constructor to registry, called from adapter init(),
+// returns false if name is already registered
+func register(name string, constructor func() IExchange) bool {
+	name = strings.ToUpper(name)
+	if _, ok := registry[name]; ok {
+		return false
+	}
+
+	registry[name] = constructor
+	return true
+}
+
+// newExchange returns registered exchange by name, nil if not registered
+func newExchange(name string) IExchange {
+	constructor, ok := registry[strings.ToUpper(name)]
+	if !ok {
+		return nil
+	}
+
+	return constructor()
+}
+
+// enabledExchanges returns exchange names to run
+// GOCRIX_EXCHANGES : comma separated exchange names (ex. UPBIT,BITHUMB)
+func enabledExchanges() []string {
+	// Generic exchanges are registered after every built-in adapter, so name collision is detected
+	registerGenerics()
+
+	var names []string
+	for _, name := range splitEnv("GOCRIX_EXCHANGES", defaultExchanges, true) {
+		if _, ok := registry[name]; !ok {
+			logger.Log.Errorf("Unknown exchange %s in GOCRIX_EXCHANGES, skip", name)
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	return names
+}
+
+// mergeAssets appends assets not in dst
+func mergeAssets(dst []string, assets []string) []string {
+	exists := make(map[string]struct{}, len(dst))
+	for _, v := range dst {
+		exists[v] = struct{}{}
+	}
+
+	for _, v := range assets {
+		if _, ok := exists[v]; ok {
+			continue
+		}
+
+		exists[v] = struct{}{}
+		dst = append(dst, v)
+	}
+
+	return dst
+}
@@ -0,0 +1,211 @@
+package exchange
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jeongpope/go-crix/logger"
+	"github.com/jeongpope/go-crix/utils"
+)
+
+var (
+	// GOCRIX_REST_TIMEOUT : REST request timeout (second)
+	restTimeout = durationEnv("GOCRIX_REST_TIMEOUT", time.Second*10)
+	// GOCRIX_REST_RATE : requests per second of request group, upbit quotation limit is 10
+	restRate = utils.IntEnv("GOCRIX_REST_RATE", 10)
+	// GOCRIX_REST_429_RETRY : retries of 429 Too Many Requests response
+	rest429Retry = utils.IntEnv("GOCRIX_REST_429_RETRY", 3)
+
+	// defaultRESTClient is shared by every REST request (snapshot, market list, polling)
+	defaultRESTClient = newRESTClient(restTimeout, float64(restRate))
+)
+
+// restClient is REST client limited by token bucket per request group,
+// group is learned from upbit Remaining-Req header and host is used until learned
+type restClient struct {
+	client *http.Client
+	rate   float64 // tokens per second of bucket
+
+	lock    *sync.Mutex
+	groups  map[string]string       // host + path : group key
+	buckets map[string]*tokenBucket // group key : bucket
+}
+
+func newRESTClient(timeout time.Duration, rate float64) *restClient {
+	if rate <= 0 {
+		rate = 1
+	}
+
+	return &restClient{
+		client:  &http.Client{Timeout: timeout},
+		rate:    rate,
+		lock:    &sync.Mutex{},
+		groups:  make(map[string]string),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// get request GET and returns response body, 429 response is retried after Retry-After or backoff
+func (rc *restClient) get(rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := utils.DefaultRetryPolicy()
+
+	for attempt := 1; ; attempt++ {
+		rc.wait(u)
+
+		resp, err := rc.client.Get(rawURL)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		group, remaining, ok := parseRemainingReq(resp.Header.Get("Remaining-Req"))
+		if ok {
+			rc.update(u, group, remaining)
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt <= rest429Retry {
+			delay := retryAfter(resp.Header.Get("Retry-After"), policy.Backoff(attempt))
+			logger.Log.Errorf("GET %s returns status 429, retry after %s", rawURL, delay)
+
+			time.Sleep(delay)
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("GET %s returns status %d", rawURL, resp.StatusCode)
+		}
+
+		return data, nil
+	}
+}
+
+// wait blocks until request of url is allowed by its group bucket
+func (rc *restClient) wait(u *url.URL) {
+	rc.lock.Lock()
+	delay := rc.bucket(u).reserve(time.Now())
+	rc.lock.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// update learn group of url and sync its bucket with remaining requests in current second
+func (rc *restClient) update(u *url.URL, group string, remaining int) {
+	rc.lock.Lock()
+	defer rc.lock.Unlock()
+
+	rc.groups[u.Host+u.Path] = u.Host + ":" + group
+	rc.bucket(u).limit(float64(remaining))
+}
+
+// bucket returns bucket of url group, lock must be held
+func (rc *restClient) bucket(u *url.URL) *tokenBucket {
+	key, ok := rc.groups[u.Host+u.Path]
+	if !ok {
+		key = u.Host
+	}
+
+	b, ok := rc.buckets[key]
+	if !ok {
+		b = newTokenBucket(rc.rate, rc.rate)
+		rc.buckets[key] = b
+	}
+
+	return b
+}
+
+// tokenBucket define token bucket, tokens become negative while requests are waiting
+type tokenBucket struct {
+	rate     float64 // tokens per second
+	capacity float64
+	tokens   float64
+	updated  time.Time
+}
+
+func newTokenBucket(rate float64, capacity float64) *tokenBucket {
+	return &tokenBucket{rate: rate, capacity: capacity, tokens: capacity}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if !b.updated.IsZero() {
+		b.tokens += now.Sub(b.updated).Seconds() * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+	}
+	b.updated = now
+}
+
+// reserve take one token, returns delay until token is available
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.refill(now)
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// limit lower tokens to remaining requests reported by server,
+// quota is shared with other instances on same address
+func (b *tokenBucket) limit(remaining float64) {
+	b.refill(time.Now())
+
+	if remaining < b.tokens {
+		b.tokens = remaining
+	}
+}
+
+// parseRemainingReq parse upbit Remaining-Req header
+// ex) group=market; min=1799; sec=9
+func parseRemainingReq(header string) (group string, remaining int, ok bool) {
+	if header == "" {
+		return "", 0, false
+	}
+
+	remaining = -1
+	for _, v := range strings.Split(header, ";") {
+		kv := strings.SplitN(strings.TrimSpace(v), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		switch kv[0] {
+		case "group":
+			group = kv[1]
+		case "sec":
+			remaining, _ = strconv.Atoi(kv[1])
+		}
+	}
+
+	return group, remaining, group != "" && remaining >= 0
+}
+
+// retryAfter returns Retry-After header delay (second), defaultDelay if not set
+func retryAfter(header string, defaultDelay time.Duration) time.Duration {
+	sec, err := strconv.Atoi(header)
+	if err != nil || sec <= 0 {
+		return defaultDelay
+	}
+
+	return time.Second * time.Duration(sec)
+}
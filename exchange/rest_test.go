@@ -0,0 +1,84 @@
+package exchange
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func Test_ParseRemainingReq(t *testing.T) {
+	group, remaining, ok := parseRemainingReq("group=market; min=1799; sec=9")
+	if !ok || group != "market" || remaining != 9 {
+		t.Errorf("parseRemainingReq() = %s, %d, %t", group, remaining, ok)
+	}
+
+	if _, _, ok := parseRemainingReq("min=1799"); ok {
+		t.Error("expected not ok without group and sec")
+	}
+}
+
+func Test_TokenBucket(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(10, 10)
+
+	for i := 0; i < 10; i++ {
+		if d := b.reserve(now); d != 0 {
+			t.Fatalf("reserve() %d = %s, expected 0", i, d)
+		}
+	}
+
+	if d := b.reserve(now); d != time.Millisecond*100 {
+		t.Errorf("reserve() over capacity = %s, expected 100ms", d)
+	}
+
+	// Refilled after a second
+	if d := b.reserve(now.Add(time.Second)); d != 0 {
+		t.Errorf("reserve() after refill = %s, expected 0", d)
+	}
+}
+
+func Test_RESTClientRetryAfter(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// First request is limited, retried after Retry-After
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Remaining-Req", "group=market; min=1799; sec=0")
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		w.Header().Set("Remaining-Req", "group=market; min=1798; sec=5")
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	rc := newRESTClient(time.Second*5, 10)
+	start := time.Now()
+
+	data, err := rc.get(server.URL + "/v1/market/all")
+	if err != nil || string(data) != "ok" {
+		t.Fatalf("get() = %s, %v", data, err)
+	}
+
+	if n := atomic.LoadInt32(&requests); n != 2 {
+		t.Errorf("requests = %d, expected 2", n)
+	}
+
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, expected Retry-After 1s", elapsed)
+	}
+
+	// Group limit is learned from Remaining-Req
+	u, _ := url.Parse(server.URL + "/v1/market/all")
+	if key := rc.groups[u.Host+u.Path]; key != u.Host+":market" {
+		t.Errorf("group of url = %s, expected %s:market", key, u.Host)
+	}
+
+	if b := rc.bucket(u); b.capacity != 10 || b.tokens > 5 {
+		t.Errorf("bucket of group not limited by Remaining-Req, tokens %f", b.tokens)
+	}
+}
@@ -1,12 +1,12 @@
 package exchange
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"fmt"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,8 +17,52 @@ import (
 	"github.com/jeongpope/go-crix/utils"
 )
 
+const (
+	defaultUpbitQuotes = "KRW"    // GOCRIX_UPBIT_QUOTES : comma separated quote assets (ex. KRW,BTC,USDT)
+	defaultUpbitTypes  = "ticker" // GOCRIX_UPBIT_TYPES : comma separated subscribe types (ex. ticker,orderbook,trade)
+
+	upbitTradeDedupSize = 10000 // remembered trade sequential ids
+
+	defaultUpbitShardSize = 50 // GOCRIX_UPBIT_SHARD_SIZE : market codes per websocket connection, 0 is single connection
+)
+
+var (
+	// GOCRIX_UPBIT_MARKET_REFRESH : market list refresh interval (second), 0 disables
+	upbitMarketRefresh = durationEnv("GOCRIX_UPBIT_MARKET_REFRESH", time.Minute*10)
+
+	kst = time.FixedZone("KST", 9*60*60) // upbit delisting date is korea date
+)
+
 type Upbit struct {
 	exchange
+
+	quotes []string // subscribe quote markets (ex. KRW, BTC, USDT)
+	types  []string // subscribe types (ex. ticker, orderbook, trade)
+
+	tradeSeen *dedupSet // received trades, kept across reconnects
+
+	shardLock *sync.Mutex
+	shardSize int                     // market codes per connection, 0 is unlimited
+	shards    []*upbitShard           // websocket connections, each subscribes part of codes
+	shardSeq  int                     // id of next shard
+	serve     func(shard *upbitShard) // set while Execute() runs, starts connection of shard
+
+	delistings map[string]string // market code : announced delisting date, guarded by updateLock
+}
+
+// upbitShard define one websocket connection and its subscribed codes
+type upbitShard struct {
+	id            int // websocket stat label (shard-<id>)
+	codes         []string
+	subsMessage   []*[]byte
+	reconnectLock *sync.Mutex
+
+	cfg    *wsConfig          // set while serving
+	cancel context.CancelFunc // set while serving, closes connection
+}
+
+func init() {
+	register("UPBIT", func() IExchange { return new(Upbit) })
 }
 
 // UpbitTickerEvent define websocket ticker statistics event
@@ -60,6 +104,43 @@ type UpbitTickerEvent struct {
 	StreamType         string  `json:"stream_type"`           // 스트림 타입
 }
 
+// UpbitOrderBookEvent define websocket orderbook event
+type UpbitOrderBookEvent struct {
+	Type           string               `json:"type"`            // 타입(orderbook : 호가)
+	Code           string               `json:"code"`            // 마켓 코드 (ex. KRW-BTC)
+	TotalAskSize   float64              `json:"total_ask_size"`  // 호가 매도 총 잔량
+	TotalBidSize   float64              `json:"total_bid_size"`  // 호가 매수 총 잔량
+	OrderBookUnits []UpbitOrderBookUnit `json:"orderbook_units"` // 호가
+	Timestamp      int64                `json:"timestamp"`       // 타임스탬프 (milliseconds)
+	StreamType     string               `json:"stream_type"`     // 스트림 타입
+}
+
+// UpbitOrderBookUnit define upbit orderbook level
+type UpbitOrderBookUnit struct {
+	AskPrice float64 `json:"ask_price"` // 매도 호가
+	BidPrice float64 `json:"bid_price"` // 매수 호가
+	AskSize  float64 `json:"ask_size"`  // 매도 잔량
+	BidSize  float64 `json:"bid_size"`  // 매수 잔량
+}
+
+// UpbitTradeEvent define websocket trade event
+type UpbitTradeEvent struct {
+	Type             string  `json:"type"`               // 타입(trade : 체결)
+	Code             string  `json:"code"`               // 마켓 코드 (ex. KRW-BTC)
+	TradePrice       float64 `json:"trade_price"`        // 체결 가격
+	TradeVolume      float64 `json:"trade_volume"`       // 체결량
+	AskBid           string  `json:"ask_bid"`            // 매수/매도 구분
+	PrevClosingPrice float64 `json:"prev_closing_price"` // 전일 종가
+	Change           string  `json:"change"`             // 전일 대비
+	ChangePrice      float64 `json:"change_price"`       // 부호 없는 전일 대비 값
+	TradeDate        string  `json:"trade_date"`         // 체결 일자(UTC)
+	TradeTime        string  `json:"trade_time"`         // 체결 시각(UTC)
+	TradeTimestamp   int64   `json:"trade_timestamp"`    // 체결 타임스탬프 (milliseconds)
+	Timestamp        int64   `json:"timestamp"`          // 타임스탬프 (milliseconds)
+	SequentialID     int64   `json:"sequential_id"`      // 체결 번호 (Unique)
+	StreamType       string  `json:"stream_type"`        // 스트림 타입
+}
+
 // UpbitTicketField define upbit websocket ticket field statistics event
 type UpbitTicketField struct {
 	Ticket string `json:"ticket,omitempty"`
@@ -82,48 +163,107 @@ type UpbitMarket struct {
 func (ex *Upbit) Initialize(currencies *[]string) []string {
 	logger.Log.Info("[upbit.go] Start Initialize()")
 
+	ex.quotes = splitEnv("GOCRIX_UPBIT_QUOTES", defaultUpbitQuotes, true)
+	ex.types = splitEnv("GOCRIX_UPBIT_TYPES", defaultUpbitTypes, false)
 	codes, coins := ex.getMarkets()
-	msg := ex.makeSubsMessage(codes)
 
 	ex.tickerEndpoint = tickerURL
 	ex.c = nil
-	ex.reconnectLock = &sync.Mutex{}
-	ex.subsMessage = append(ex.subsMessage, &msg)
+	ex.reconnectLock = nil
 	ex.chanSendMessage = nil
 
+	ex.shardLock = &sync.Mutex{}
+	ex.shardSize = utils.IntEnv("GOCRIX_UPBIT_SHARD_SIZE", defaultUpbitShardSize)
+	ex.shards = nil
+	for _, v := range chunkStrings(codes, ex.shardSize) {
+		ex.shards = append(ex.shards, ex.newShard(v))
+	}
+
 	ex.supportAssets = append(ex.supportAssets, coins...)
 	ex.tickers = make(map[string]model.Ticker)
-	ex.updateLock = nil
+	ex.updateLock = &sync.Mutex{} // tickers and orderbooks are shared by shards
+	ex.orderbooks = make(map[string]model.OrderBook)
+	ex.delistings = make(map[string]string)
+	ex.tradeSeen = newDedupSet(upbitTradeDedupSize)
 
 	ex.initSnapshot(codes)
 
+	logger.Log.Infof("[upbit.go] %d codes on %d connections", len(codes), len(ex.shards))
 	logger.Log.Info("[upbit.go] End Initialize()")
 
 	return ex.supportAssets
 }
 
-func (ex *Upbit) Execute() (err error) {
+func (ex *Upbit) Execute(ctx context.Context) (err error) {
 	logger.Log.Info("[upbit.go] Start Execute()")
 
 	handler := func(event *UpbitTickerEvent) {
-		name := event.Code[4:]
-
-		if ex.tickers[name].Price != event.TradePrice {
-			tempTicker := model.Ticker{
-				Exchange:       "UPBIT",
-				Currency:       event.Code[4:],
-				Price:          event.TradePrice,
-				YesterdayPrice: event.PrevClosingPrice,
-				Change:         event.SignedChangePrice,
-				ChangeRate:     event.SignedChangeRate,
-				Volume:         uint(event.AccTradePrice24h),
-			}
+		base, quote := splitUpbitCode(event.Code)
 
-			ex.tickers[name] = tempTicker
-			logger.Log.Info("[TICKER] ", tempTicker)
+		if event.DelistingDate != "" {
+			ex.updateLock.Lock()
+			ex.delistings[event.Code] = event.DelistingDate
+			ex.updateLock.Unlock()
+		}
+
+		ex.update(event.Code, model.Ticker{
+			Exchange:       "UPBIT",
+			Currency:       base,
+			Quote:          quote,
+			Price:          event.TradePrice,
+			YesterdayPrice: event.PrevClosingPrice,
+			Change:         event.SignedChangePrice,
+			ChangeRate:     event.SignedChangeRate,
+			Volume:         uint(event.AccTradePrice24h),
+			MarketWarning:  event.MarketWarning,
+		})
+	}
 
-			ex.chanSendMessage <- tempTicker
+	orderbookHandler := func(event *UpbitOrderBookEvent) {
+		base, quote := splitUpbitCode(event.Code)
+
+		ob := model.OrderBook{
+			Exchange:     "UPBIT",
+			Currency:     base,
+			Quote:        quote,
+			Asks:         make([]model.OrderBookUnit, 0, len(event.OrderBookUnits)),
+			Bids:         make([]model.OrderBookUnit, 0, len(event.OrderBookUnits)),
+			TotalAskSize: event.TotalAskSize,
+			TotalBidSize: event.TotalBidSize,
+			Timestamp:    event.Timestamp,
 		}
+
+		// Upbit sends whole levels for each message, units are ordered from the top of book
+		for _, v := range event.OrderBookUnits {
+			ob.Asks = append(ob.Asks, model.OrderBookUnit{Price: v.AskPrice, Size: v.AskSize})
+			ob.Bids = append(ob.Bids, model.OrderBookUnit{Price: v.BidPrice, Size: v.BidSize})
+		}
+
+		ex.updateLock.Lock()
+		ex.orderbooks[event.Code] = ob
+		ex.updateLock.Unlock()
+
+		ex.sendOrderBook(ob)
+	}
+
+	tradeHandler := func(event *UpbitTradeEvent) {
+		// sequential_id is unique but not ordered, snapshot on reconnect resends the last trade
+		if !ex.tradeSeen.add(event.Code + ":" + strconv.FormatInt(event.SequentialID, 10)) {
+			return
+		}
+
+		base, quote := splitUpbitCode(event.Code)
+
+		ex.sendTrade(model.Trade{
+			Exchange:     "UPBIT",
+			Currency:     base,
+			Quote:        quote,
+			Price:        event.TradePrice,
+			Volume:       event.TradeVolume,
+			Side:         event.AskBid,
+			SequentialID: event.SequentialID,
+			Timestamp:    event.TradeTimestamp,
+		})
 	}
 
 	errHandler := func(err error) {
@@ -132,27 +272,341 @@ func (ex *Upbit) Execute() (err error) {
 
 	// Serve
 	wsHandler := func(message []byte) {
-		event := new(UpbitTickerEvent)
-		err := json.Unmarshal(message, event)
+		var head struct {
+			Type string `json:"type"`
+		}
+		err := json.Unmarshal(message, &head)
 
 		if err != nil {
 			errHandler(err)
 			return
 		}
 
-		handler(event)
+		switch head.Type {
+		case "ticker":
+			event := new(UpbitTickerEvent)
+			if err := json.Unmarshal(message, event); err != nil {
+				errHandler(err)
+				return
+			}
+
+			handler(event)
+		case "orderbook":
+			event := new(UpbitOrderBookEvent)
+			if err := json.Unmarshal(message, event); err != nil {
+				errHandler(err)
+				return
+			}
+
+			orderbookHandler(event)
+		case "trade":
+			event := new(UpbitTradeEvent)
+			if err := json.Unmarshal(message, event); err != nil {
+				errHandler(err)
+				return
+			}
+
+			tradeHandler(event)
+		}
+	}
+
+	// Serve each shard on its own connection, dropped connection affects its codes only
+	var wg sync.WaitGroup
+
+	ex.shardLock.Lock()
+	ex.serve = func(shard *upbitShard) {
+		shardCtx, cancel := context.WithCancel(ctx)
+
+		shard.cancel = cancel
+		shard.cfg = &wsConfig{
+			endpoint:    ex.tickerEndpoint,
+			label:       "shard-" + strconv.Itoa(shard.id),
+			subsMessage: shard.subsMessage,
+			resync: func() {
+				ex.shardLock.Lock()
+				codes := append([]string(nil), shard.codes...)
+				ex.shardLock.Unlock()
+
+				ex.resync(codes)
+			},
+		}
+
+		wg.Add(1)
+		go func(cfg *wsConfig) {
+			defer wg.Done()
+			defer cancel()
+
+			websocketServe(shardCtx, nil, shard.reconnectLock, cfg, wsHandler, errHandler)
+		}(shard.cfg)
+	}
+
+	for _, shard := range ex.shards {
+		// Shard without codes has nothing to subscribe
+		if len(shard.codes) == 0 {
+			continue
+		}
+
+		ex.serve(shard)
+	}
+	ex.shardLock.Unlock()
+
+	if upbitMarketRefresh > 0 {
+		go ex.refreshMarkets(ctx)
+	}
+
+	// Keep running without shards, codes may be subscribed later
+	<-ctx.Done()
+
+	ex.shardLock.Lock()
+	ex.serve = nil
+	ex.shardLock.Unlock()
+
+	wg.Wait()
+
+	return nil
+}
+
+// Subscribe add market codes at runtime,
+// codes fill the last shard and overflow opens new shard
+func (ex *Upbit) Subscribe(codes []string) error {
+	ex.shardLock.Lock()
+	defer ex.shardLock.Unlock()
+
+	subscribed := make(map[string]struct{})
+	for _, shard := range ex.shards {
+		for _, v := range shard.codes {
+			subscribed[v] = struct{}{}
+		}
+	}
+
+	var added []string
+	for _, v := range codes {
+		code := strings.ToUpper(strings.TrimSpace(v))
+		if _, ok := subscribed[code]; ok {
+			continue
+		}
+
+		_, quote := splitUpbitCode(code)
+		if !ex.isSupportQuote(quote) {
+			return fmt.Errorf("unsupported market code %s", v)
+		}
+
+		subscribed[code] = struct{}{}
+		added = append(added, code)
+	}
+
+	for len(added) > 0 {
+		var last *upbitShard
+		if len(ex.shards) > 0 {
+			last = ex.shards[len(ex.shards)-1]
+		}
+
+		n := len(added)
+		if last == nil || (ex.shardSize > 0 && len(last.codes) >= ex.shardSize) {
+			if ex.shardSize > 0 && n > ex.shardSize {
+				n = ex.shardSize
+			}
+
+			shard := ex.newShard(added[:n])
+			ex.shards = append(ex.shards, shard)
+			if ex.serve != nil {
+				ex.serve(shard)
+			}
+
+			logger.Log.Infof("[upbit.go] open shard %d, %v", len(ex.shards), shard.codes)
+		} else {
+			if ex.shardSize > 0 && n > ex.shardSize-len(last.codes) {
+				n = ex.shardSize - len(last.codes)
+			}
+
+			ex.resubscribeShard(last, append(last.codes, added[:n]...))
+		}
+
+		added = added[n:]
+	}
+
+	ex.updateSupportAssets()
+
+	return nil
+}
+
+// Unsubscribe remove market codes at runtime, shard without codes is closed
+func (ex *Upbit) Unsubscribe(codes []string) error {
+	ex.shardLock.Lock()
+	defer ex.shardLock.Unlock()
+
+	removed := make(map[string]struct{})
+	for _, v := range codes {
+		removed[strings.ToUpper(strings.TrimSpace(v))] = struct{}{}
+	}
+
+	var shards []*upbitShard
+	for _, shard := range ex.shards {
+		var remain []string
+		for _, v := range shard.codes {
+			if _, ok := removed[v]; !ok {
+				remain = append(remain, v)
+			}
+		}
+
+		switch {
+		case len(remain) == len(shard.codes):
+			shards = append(shards, shard)
+		case len(remain) == 0:
+			if shard.cancel != nil {
+				shard.cancel()
+			}
+
+			logger.Log.Infof("[upbit.go] close shard, %v", shard.codes)
+		default:
+			ex.resubscribeShard(shard, remain)
+			shards = append(shards, shard)
+		}
+	}
+	ex.shards = shards
+
+	ex.updateLock.Lock()
+	for code := range removed {
+		delete(ex.tickers, code)
+		delete(ex.orderbooks, code)
+	}
+	ex.updateLock.Unlock()
+
+	ex.updateSupportAssets()
+
+	return nil
+}
+
+// refreshMarkets subscribe new listings and drop delisted markets periodically
+func (ex *Upbit) refreshMarkets(ctx context.Context) {
+	tTicker := time.NewTicker(upbitMarketRefresh)
+	defer tTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-tTicker.C:
+		}
+
+		ex.refresh(time.Now())
+	}
+}
+
+func (ex *Upbit) refresh(now time.Time) {
+	data, err := restGet(marketURL)
+	if err != nil {
+		logger.Log.Error("Upbit refresh() failed, retry next refresh : ", err)
+		return
+	}
+
+	var markets []UpbitMarket
+	err = json.Unmarshal(data, &markets)
+	if err != nil {
+		logger.Log.Error("Upbit refresh() error parsing JSON: ", err)
+		return
+	}
+
+	var listed []string
+	for _, market := range markets {
+		_, quote := splitUpbitCode(market.Market)
+		if ex.isSupportQuote(quote) {
+			listed = append(listed, market.Market)
+		}
+	}
+
+	ex.shardLock.Lock()
+	var active []string
+	for _, shard := range ex.shards {
+		active = append(active, shard.codes...)
+	}
+	ex.shardLock.Unlock()
+
+	ex.updateLock.Lock()
+	delistings := make(map[string]string, len(ex.delistings))
+	for k, v := range ex.delistings {
+		delistings[k] = v
+	}
+	ex.updateLock.Unlock()
+
+	added, removed := diffUpbitMarkets(active, listed, delistings, now)
+
+	if len(added) > 0 {
+		err = ex.Subscribe(added)
+		if err != nil {
+			logger.Log.Error("Upbit refresh() subscribe failed : ", err)
+		} else {
+			ex.sendMarketEvents(added, model.MarketListing, delistings, now)
+		}
+	}
+
+	if len(removed) > 0 {
+		err = ex.Unsubscribe(removed)
+		if err != nil {
+			logger.Log.Error("Upbit refresh() unsubscribe failed : ", err)
+		} else {
+			ex.sendMarketEvents(removed, model.MarketDelisting, delistings, now)
+		}
 	}
 
-	return websocketServe(ex.c, ex.reconnectLock,
-		ex.tickerEndpoint, ex.subsMessage, wsHandler, errHandler)
+	// Delisting date keeps code out until market is removed from list
+	listedSet := make(map[string]struct{}, len(listed))
+	for _, v := range listed {
+		listedSet[v] = struct{}{}
+	}
+
+	ex.updateLock.Lock()
+	for code := range ex.delistings {
+		if _, ok := listedSet[code]; !ok {
+			delete(ex.delistings, code)
+		}
+	}
+	ex.updateLock.Unlock()
+
+	if len(added) > 0 || len(removed) > 0 {
+		logger.Log.Infof("[upbit.go] markets refreshed, listed %v, delisted %v", added, removed)
+	}
+}
+
+func (ex *Upbit) sendMarketEvents(codes []string, eventType string, delistings map[string]string, now time.Time) {
+	for _, code := range codes {
+		base, quote := splitUpbitCode(code)
+
+		ex.sendMarketEvent(model.MarketEvent{
+			Exchange:      "UPBIT",
+			Code:          code,
+			Currency:      base,
+			Quote:         quote,
+			Type:          eventType,
+			DelistingDate: delistings[code],
+			Timestamp:     now.UnixNano() / int64(time.Millisecond),
+		})
+	}
+}
+
+// SupportAssets returns assets of subscribed codes
+func (ex *Upbit) SupportAssets() []string {
+	ex.updateLock.Lock()
+	defer ex.updateLock.Unlock()
+
+	return append([]string(nil), ex.supportAssets...)
 }
 
 func (ex *Upbit) Release() {
 	logger.Log.Info("[upbit.go] Start Release()")
 
-	if ex.c != nil {
-		ex.c.Close()
+	// Every shard has own connection
+	ex.shardLock.Lock()
+	for _, shard := range ex.shards {
+		if shard.cancel != nil {
+			shard.cancel()
+		}
+
+		if shard.cfg != nil {
+			shard.cfg.closeConn()
+		}
 	}
+	ex.shardLock.Unlock()
 
 	logger.Log.Info("[upbit.go] End Release())")
 }
@@ -160,43 +614,63 @@ func (ex *Upbit) Release() {
 func (ex *Upbit) initSnapshot(codes []string) {
 	logger.Log.Info("[upbit.go] Start initSnapshot()")
 
-	var markets string
-	for _, v := range codes {
-		markets += v + ","
+	tickers, err := ex.getSnapshot(codes)
+	if err != nil {
+		logger.Log.Error("Upbit initSnapshot() failed : ", err)
+		return
 	}
 
-	markets = markets[:len(markets)-1]
+	ex.updateLock.Lock()
+	for code, ticker := range tickers {
+		ex.tickers[code] = ticker
+	}
+	ex.updateLock.Unlock()
+
+	logger.Log.Info("[upbit.go] initSnapshot close")
+}
 
-	resp, err := http.Get(snapshotURL + markets)
+// resync update tickers by REST snapshot after reconnect,
+// tickers changed while disconnected are sent
+func (ex *Upbit) resync(codes []string) {
+	tickers, err := ex.getSnapshot(codes)
 	if err != nil {
-		logger.Log.Error("Upbit updateSnapshot() failed")
+		logger.Log.Error("Upbit resync() failed : ", err)
 		return
 	}
-	defer resp.Body.Close()
 
-	data, err := ioutil.ReadAll(resp.Body)
-	if err != nil { // Failed read response body
-		logger.Log.Error("Upbit updateSnapshot() read response body return err, retry after 10 second")
-		return
+	for code, ticker := range tickers {
+		ex.update(code, ticker)
 	}
 
-	// Parse JSON
-	var f []interface{}
-	json.Unmarshal(data, &f)
+	logger.Log.Infof("[upbit.go] resynced %d codes", len(tickers))
+}
+
+func (ex *Upbit) getSnapshot(codes []string) (map[string]model.Ticker, error) {
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	data, err := restGet(snapshotURL + strings.Join(codes, ","))
 	if err != nil {
-		logger.Log.Error("Upbit updateSnapshot() error parsing JSON: ", err)
-		return
+		return nil, err
 	}
 
-	// Loop through the items
-	for _, value := range f {
-		dataMap := value.(map[string]interface{})
+	// Parse JSON
+	var f []map[string]interface{}
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return nil, err
+	}
 
-		currency := dataMap["market"].(string)[len("KRW-"):]
+	tickers := make(map[string]model.Ticker, len(f))
+	for _, dataMap := range f {
+		code, _ := dataMap["market"].(string)
+		base, quote := splitUpbitCode(code)
 
-		ex.tickers[currency] = model.Ticker{
+		tickers[code] = model.Ticker{
 			Exchange:       "UPBIT",
-			Currency:       currency,
+			Currency:       base,
+			Quote:          quote,
 			Price:          utils.ToFloat64(dataMap["trade_price"]),
 			YesterdayPrice: utils.ToFloat64(dataMap["prev_closing_price"]),
 			Change:         utils.ToFloat64(dataMap["signed_change_price"]),
@@ -205,59 +679,153 @@ func (ex *Upbit) initSnapshot(codes []string) {
 		}
 	}
 
-	logger.Log.Info("[upbit.go] initSnapshot close")
+	return tickers, nil
 }
 
 // -----
+func (ex *Upbit) newShard(codes []string) *upbitShard {
+	// Copy codes, chunks share backing array and shard codes grow by Subscribe
+	codes = append([]string(nil), codes...)
+	msg := ex.makeSubsMessage(codes)
+
+	ex.shardSeq++
+
+	return &upbitShard{
+		id:            ex.shardSeq - 1,
+		codes:         codes,
+		subsMessage:   []*[]byte{&msg},
+		reconnectLock: &sync.Mutex{},
+	}
+}
+
+// resubscribeShard replace codes of shard, upbit replaces subscription of connection by new request
+func (ex *Upbit) resubscribeShard(shard *upbitShard, codes []string) {
+	msg := ex.makeSubsMessage(codes)
+
+	shard.codes = codes
+	shard.subsMessage = []*[]byte{&msg}
+
+	if shard.cfg != nil {
+		err := shard.cfg.resubscribe(shard.subsMessage)
+		if err != nil {
+			logger.Log.Error("Upbit resubscribe failed, retried after reconnect : ", err)
+		}
+	}
+}
+
+// updateSupportAssets rebuild supportAssets from codes of shards, shardLock must be held
+func (ex *Upbit) updateSupportAssets() {
+	var assets []string
+	for _, shard := range ex.shards {
+		for _, v := range shard.codes {
+			base, _ := splitUpbitCode(v)
+			assets = mergeAssets(assets, []string{base})
+		}
+	}
+
+	ex.updateLock.Lock()
+	ex.supportAssets = assets
+	ex.updateLock.Unlock()
+}
+
+// update store ticker and send if price changed, called by every shard
+func (ex *Upbit) update(code string, ticker model.Ticker) {
+	ex.updateLock.Lock()
+	changed := ex.tickers[code].Price != ticker.Price
+	if changed {
+		ex.tickers[code] = ticker
+	}
+	ex.updateLock.Unlock()
+
+	if changed {
+		logger.Log.Info("[TICKER] ", ticker)
+
+		ex.chanSendMessage <- ticker
+	}
+}
+
 func (ex *Upbit) getMarkets() ([]string, []string) {
-	var resp *http.Response
-	var err error
-	var data []byte
 	var codes []string
 	var currencies []string
 
 	var markets []UpbitMarket
 
-	for {
-		resp, err = http.Get(marketURL)
-		if err != nil {
-			logger.Log.Error("Upbit getMarkets() restServe return err, retry after 10 second")
+	err := restGetJSON("Upbit getMarkets()", marketURL, &markets)
+	if err != nil {
+		logger.Log.Error("Upbit getMarkets() failed : ", err)
+		return nil, nil
+	}
 
-			time.Sleep(time.Second * 10)
+	for _, market := range markets {
+		base, quote := splitUpbitCode(market.Market)
+		if !ex.isSupportQuote(quote) {
 			continue
-		} else {
-			data, err = ioutil.ReadAll(resp.Body)
-
-			if err != nil {
-				logger.Log.Error("Upbit getMarkets() read response body return err, retry after 10 second")
-
-				resp.Body.Close()
-				time.Sleep(time.Second * 10)
-			} else {
-				err = json.Unmarshal(data, &markets)
-				if err != nil {
-					logger.Log.Error("Upbit getMarkets() unmarshal response body return err, retry after 10 second")
-
-					resp.Body.Close()
-					time.Sleep(time.Second * 10)
-				}
-
-				for _, market := range markets {
-					if strings.HasPrefix(market.Market, "KRW-") {
-						codes = append(codes, market.Market)
-						currencies = append(currencies, market.Market[4:])
-					}
-				}
-			}
 		}
 
-		break
+		codes = append(codes, market.Market)
+		currencies = mergeAssets(currencies, []string{base})
 	}
-	defer resp.Body.Close()
 
 	return codes, currencies
 }
 
+func (ex *Upbit) isSupportQuote(quote string) bool {
+	for _, v := range ex.quotes {
+		if v == quote {
+			return true
+		}
+	}
+
+	return false
+}
+
+// diffUpbitMarkets returns listed codes not in active and active codes delisted,
+// code is delisted if it is not listed or its delisting date (korea date) has come
+func diffUpbitMarkets(active []string, listed []string, delistings map[string]string, now time.Time) (added []string, removed []string) {
+	delisted := func(code string) bool {
+		date, ok := delistings[code]
+		if !ok {
+			return false
+		}
+
+		t, err := time.ParseInLocation("2006-01-02", date, kst)
+		return err == nil && !now.Before(t)
+	}
+
+	listedSet := make(map[string]struct{}, len(listed))
+	for _, v := range listed {
+		listedSet[v] = struct{}{}
+	}
+
+	activeSet := make(map[string]struct{}, len(active))
+	for _, v := range active {
+		activeSet[v] = struct{}{}
+
+		if _, ok := listedSet[v]; !ok || delisted(v) {
+			removed = append(removed, v)
+		}
+	}
+
+	for _, v := range listed {
+		if _, ok := activeSet[v]; !ok && !delisted(v) {
+			added = append(added, v)
+		}
+	}
+
+	return added, removed
+}
+
+// splitUpbitCode split upbit market code into base and quote asset
+// ex) KRW-BTC : BTC, KRW / BTC-ETH : ETH, BTC
+func splitUpbitCode(code string) (base string, quote string) {
+	idx := strings.Index(code, "-")
+	if idx < 0 {
+		return code, ""
+	}
+
+	return code[idx+1:], code[:idx]
+}
+
 func makeSignature() string {
 	timestamp := strconv.FormatInt(time.Now().UnixNano()/int64(time.Millisecond), 10)
 
@@ -273,14 +841,18 @@ func makeSignature() string {
 
 func (ex *Upbit) makeSubsMessage(codes []string) []byte {
 	// Subscribe message format
-	// [{ticket}, {type}, {format}]
+	// [{ticket}, {type}, {type}, ..., {format}]
 	msg := []interface{}{}
 	i := UpbitTicketField{makeSignature()}
-	j := UpbitTypeField{
-		Type:  "ticker",
-		Codes: codes,
+	msg = append(msg, i)
+
+	for _, t := range ex.types {
+		j := UpbitTypeField{
+			Type:  t,
+			Codes: codes,
+		}
+		msg = append(msg, j)
 	}
-	msg = append(msg, i, j)
 	tickerMsg, _ := json.Marshal(msg)
 
 	return tickerMsg
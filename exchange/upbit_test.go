@@ -0,0 +1,77 @@
+package exchange
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jeongpope/go-crix/model"
+)
+
+func Test_UpbitSubscribeShards(t *testing.T) {
+	ex := &Upbit{
+		quotes:    []string{"KRW"},
+		shardLock: &sync.Mutex{},
+		shardSize: 2,
+	}
+	ex.updateLock = &sync.Mutex{}
+	ex.tickers = make(map[string]model.Ticker)
+	ex.orderbooks = make(map[string]model.OrderBook)
+
+	err := ex.Subscribe([]string{"KRW-BTC", "krw-eth", "KRW-XRP", "KRW-BTC"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ex.shards) != 2 || !reflect.DeepEqual(ex.shards[1].codes, []string{"KRW-XRP"}) {
+		t.Fatalf("unexpected shards after subscribe %v", ex.shards)
+	}
+
+	if err := ex.Subscribe([]string{"BTC-ETH"}); err == nil {
+		t.Error("expected error for unsupported quote")
+	}
+
+	ex.Unsubscribe([]string{"KRW-XRP", "KRW-ETH"})
+
+	if len(ex.shards) != 1 || !reflect.DeepEqual(ex.shards[0].codes, []string{"KRW-BTC"}) {
+		t.Fatalf("unexpected shards after unsubscribe %v", ex.shards)
+	}
+
+	if assets := ex.SupportAssets(); !reflect.DeepEqual(assets, []string{"BTC"}) {
+		t.Errorf("SupportAssets() = %v, expected [BTC]", assets)
+	}
+}
+
+func Test_DiffUpbitMarkets(t *testing.T) {
+	now := time.Date(2024, 3, 1, 0, 0, 0, 0, kst)
+
+	active := []string{"KRW-BTC", "KRW-ETH", "KRW-XRP"}
+	listed := []string{"KRW-BTC", "KRW-XRP", "KRW-SOL", "KRW-OLD"}
+	delistings := map[string]string{
+		"KRW-XRP": "2024-03-01", // delisting date has come
+		"KRW-BTC": "2024-03-02", // announced, still collected
+		"KRW-OLD": "2024-02-01",
+	}
+
+	added, removed := diffUpbitMarkets(active, listed, delistings, now)
+
+	if !reflect.DeepEqual(added, []string{"KRW-SOL"}) {
+		t.Errorf("added = %v, expected [KRW-SOL]", added)
+	}
+
+	if !reflect.DeepEqual(removed, []string{"KRW-ETH", "KRW-XRP"}) {
+		t.Errorf("removed = %v, expected [KRW-ETH KRW-XRP]", removed)
+	}
+}
+
+func Test_ChunkStrings(t *testing.T) {
+	if chunks := chunkStrings(nil, 2); len(chunks) != 0 {
+		t.Errorf("expected no chunk of empty values, got %v", chunks)
+	}
+
+	chunks := chunkStrings([]string{"A", "B", "C"}, 2)
+	if !reflect.DeepEqual(chunks, [][]string{{"A", "B"}, {"C"}}) {
+		t.Errorf("unexpected chunks %v", chunks)
+	}
+}
@@ -4,4 +4,36 @@ const (
 	marketURL   = "https://api.upbit.com/v1/market/all?isDetails=true" // Markets
 	tickerURL   = "wss://api.upbit.com/websocket/v1"                   // Tickers, WEBSOCKET API
 	snapshotURL = "https://api.upbit.com/v1/ticker?markets="           // Snapshot
+
+	bithumbMarketURL   = "https://api.bithumb.com/v1/market/all"         // Markets
+	bithumbTickerURL   = "wss://pubwss.bithumb.com/pub/ws"               // Tickers, WEBSOCKET API
+	bithumbSnapshotURL = "https://api.bithumb.com/public/ticker/ALL_KRW" // Snapshot
+
+	binanceMarketURL   = "https://api.binance.com/api/v3/exchangeInfo?permissions=SPOT" // Markets
+	binanceTickerURL   = "wss://stream.binance.com:9443/stream"                         // Tickers, WEBSOCKET API (combined stream)
+	binanceSnapshotURL = "https://api.binance.com/api/v3/ticker/24hr"                   // Snapshot
+
+	okxMarketURL   = "https://www.okx.com/api/v5/public/instruments?instType=SPOT" // Markets
+	okxTickerURL   = "wss://ws.okx.com:8443/ws/v5/public"                          // Tickers, WEBSOCKET API
+	okxSnapshotURL = "https://www.okx.com/api/v5/market/tickers?instType=SPOT"     // Snapshot
+
+	huobiMarketURL   = "https://api.huobi.pro/v1/common/symbols" // Markets
+	huobiTickerURL   = "wss://api.huobi.pro/ws"                  // Tickers, WEBSOCKET API
+	huobiSnapshotURL = "https://api.huobi.pro/market/tickers"    // Snapshot
+
+	coinbaseMarketURL   = "https://api.exchange.coinbase.com/products"          // Markets
+	coinbaseTickerURL   = "wss://ws-feed.exchange.coinbase.com"                 // Tickers, WEBSOCKET API
+	coinbaseSnapshotURL = "https://api.exchange.coinbase.com/products/%s/stats" // Snapshot (per product)
+
+	korbitMarketURL   = "https://api.korbit.co.kr/v2/currencyPairs" // Markets
+	korbitTickerURL   = "wss://ws-api.korbit.co.kr/v2/public"       // Tickers, WEBSOCKET API
+	korbitSnapshotURL = "https://api.korbit.co.kr/v2/tickers"       // Snapshot
+
+	coinoneMarketURL   = "https://api.coinone.co.kr/public/v2/markets/KRW"    // Markets
+	coinoneTickerURL   = "wss://stream.coinone.co.kr"                         // Tickers, WEBSOCKET API
+	coinoneSnapshotURL = "https://api.coinone.co.kr/public/v2/ticker_new/KRW" // Snapshot
+
+	krakenMarketURL   = "https://api.kraken.com/0/public/AssetPairs" // Markets
+	krakenTickerURL   = "wss://ws.kraken.com"                        // Tickers, Books, WEBSOCKET API
+	krakenSnapshotURL = "https://api.kraken.com/0/public/Ticker"     // Snapshot
 )
@@ -1,87 +1,476 @@
 package exchange
 
 import (
+	"context"
 	"log"
+	"net"
+	"os"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 	"github.com/jeongpope/go-crix/logger"
+	"github.com/jeongpope/go-crix/utils"
 )
 
 var (
-	reconnectInterval = 10
+	// GOCRIX_WS_PING_INTERVAL : websocket ping interval (second), 0 disables
+	pingInterval = durationEnv("GOCRIX_WS_PING_INTERVAL", time.Second*30)
+	// GOCRIX_WS_READ_TIMEOUT : read deadline extended by every message and pong (second), 0 disables
+	readTimeout = durationEnv("GOCRIX_WS_READ_TIMEOUT", time.Second*90)
+	// GOCRIX_WS_STALE_TIMEOUT : reconnect if no message for this duration (second), 0 disables
+	staleTimeout = durationEnv("GOCRIX_WS_STALE_TIMEOUT", time.Second*120)
+
+	// GOCRIX_POLL_AFTER : failed dial attempts before REST polling fallback, 0 disables
+	pollAfter = utils.IntEnv("GOCRIX_POLL_AFTER", 3)
+	// GOCRIX_POLL_INTERVAL : REST polling interval while websocket is unavailable (second)
+	pollInterval = durationEnv("GOCRIX_POLL_INTERVAL", time.Second*5)
+)
+
+const (
+	modeStreaming = "streaming" // receiving from websocket
+	modePolling   = "polling"   // websocket unavailable, polling REST snapshot
+)
+
+// WebsocketStat counts websocket connection events per connection (endpoint, or endpoint#label)
+type WebsocketStat struct {
+	Reconnects      uint64 `json:"reconnects"`
+	PingsSent       uint64 `json:"pings_sent"`
+	PongsReceived   uint64 `json:"pongs_received"`
+	ReadTimeouts    uint64 `json:"read_timeouts"`
+	StaleReconnects uint64 `json:"stale_reconnects"`
+	Polls           uint64 `json:"polls"`
+	Mode            string `json:"mode"` // modeStreaming, modePolling
+}
+
+var (
+	wsStatLock = &sync.Mutex{}
+	wsStats    = make(map[string]*WebsocketStat)
 )
 
-var websocketServe = func(c *websocket.Conn, m *sync.Mutex,
-	endpoint string, msg []*[]byte, handler Handler, errHandler ErrHandler) (err error) {
-	c = connect(endpoint)
+// countEvent update websocket stat of connection
+func countEvent(key string, update func(stat *WebsocketStat)) {
+	wsStatLock.Lock()
+	defer wsStatLock.Unlock()
+
+	stat, ok := wsStats[key]
+	if !ok {
+		stat = &WebsocketStat{}
+		wsStats[key] = stat
+	}
+
+	update(stat)
+}
+
+// websocketStats returns copy of websocket stats by connection
+func websocketStats() map[string]WebsocketStat {
+	wsStatLock.Lock()
+	defer wsStatLock.Unlock()
+
+	stats := make(map[string]WebsocketStat, len(wsStats))
+	for k, v := range wsStats {
+		stats[k] = *v
+	}
+
+	return stats
+}
+
+// Decoder decode raw websocket frame (ex. decompress) before handler
+type Decoder func(messageType int, message []byte) ([]byte, error)
+
+// ProtocolHandler handle protocol level message (ex. server ping) before Handler,
+// returns true if message is consumed. reply writes to current connection
+type ProtocolHandler func(message []byte, reply func(messageType int, data []byte) error) bool
+
+// wsConfig define websocket serve options
+type wsConfig struct {
+	endpoint    string    // endpoint
+	label       string    // optional, distinguishes connections of same endpoint in stats (ex. upbit shard)
+	subsMessage []*[]byte // subscribe request message, resent after reconnect
+
+	decoder  Decoder         // optional, frame decoder
+	protocol ProtocolHandler // optional, protocol message handler
+
+	keepaliveMessage  []byte        // optional, text message sent periodically (ex. "ping")
+	keepaliveInterval time.Duration // keepalive message interval
+
+	resync func() // optional, REST snapshot resync, called after reconnect and polled while websocket is unavailable
+
+	send  func(messageType int, data []byte) error // set by websocketServe, writes to current connection
+	close func()                                   // set by websocketServe, closes current connection
+	lock  sync.Mutex                               // guards subsMessage, send and close
+}
+
+// closeConn close current connection if serving, used with cancel of serve ctx to release immediately
+func (cfg *wsConfig) closeConn() {
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
+
+	if cfg.close != nil {
+		cfg.close()
+	}
+}
+
+// statKey returns websocket stat key of connection
+func (cfg *wsConfig) statKey() string {
+	if cfg.label == "" {
+		return cfg.endpoint
+	}
+
+	return cfg.endpoint + "#" + cfg.label
+}
+
+// resubscribe replace subscribe messages and sends them on current connection,
+// replaced messages are also sent after reconnect
+func (cfg *wsConfig) resubscribe(msgs []*[]byte) error {
+	cfg.lock.Lock()
+	defer cfg.lock.Unlock()
+
+	cfg.subsMessage = msgs
+
+	// Not connected yet, messages are sent on connect
+	if cfg.send == nil {
+		return nil
+	}
+
+	for _, v := range msgs {
+		err := cfg.send(websocket.TextMessage, *v)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// websocketServe serve websocket until ctx is cancelled or reconnect gives up,
+// cancelling ctx stops dialing and closes current connection
+var websocketServe = func(ctx context.Context, c *websocket.Conn, m *sync.Mutex,
+	cfg *wsConfig, handler Handler, errHandler ErrHandler) (err error) {
+	// Stops background go-routines when serve returns
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Last message time (unix nano), checked by stale watchdog
+	var lastMessage int64
+
+	// Install pong handler and read deadline on new connection
+	prepare := func(conn *websocket.Conn) {
+		atomic.StoreInt64(&lastMessage, time.Now().UnixNano())
+
+		if readTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(readTimeout))
+		}
+
+		conn.SetPongHandler(func(string) error {
+			countEvent(cfg.statKey(), func(stat *WebsocketStat) { stat.PongsReceived++ })
+
+			if readTimeout > 0 {
+				conn.SetReadDeadline(time.Now().Add(readTimeout))
+			}
+			return nil
+		})
+	}
+
+	c, err = connect(ctx, cfg)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+	prepare(c)
+
+	// gorilla/websocket supports one concurrent writer,
+	// every write and connection replacement hold writeLock
+	writeLock := &sync.Mutex{}
+	write := func(messageType int, data []byte) error {
+		writeLock.Lock()
+		defer writeLock.Unlock()
 
+		return c.WriteMessage(messageType, data)
+	}
+	// Close current connection, blocked ReadMessage returns error and reconnects
+	closeCurrent := func() {
+		writeLock.Lock()
+		defer writeLock.Unlock()
+
+		c.Close()
+	}
+
+	cfg.lock.Lock()
+	cfg.send = write
+	cfg.close = closeCurrent
+	cfg.lock.Unlock()
+
+	// Connection generation, subscribe of previous connection stops after reconnect
+	var generation int64
+
+	// subscribe runs in own go-routine, adapters with one frame per market (ex. huobi, coinone)
+	// take minutes to subscribe and reader must keep answering pings meanwhile
+	subscribe := func(gen int64) {
+		tTicker := time.NewTicker(time.Millisecond * 250)
+		defer tTicker.Stop()
+
+		// subsMessage may be replaced by resubscribe while sending
+		for i := 0; ; i++ {
+			cfg.lock.Lock()
+			if i >= len(cfg.subsMessage) || atomic.LoadInt64(&generation) != gen {
+				cfg.lock.Unlock()
+				return
+			}
+			write(websocket.TextMessage, *cfg.subsMessage[i])
+			cfg.lock.Unlock()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-tTicker.C:
+			}
+		}
+	}
+
+	// Close connection on cancel, blocked ReadMessage returns error and loop exits
 	go func() {
-		log.Println("[websocket.go] websocketServe go-routine")
+		<-ctx.Done()
+		closeCurrent()
+	}()
+
+	if cfg.keepaliveMessage != nil {
 		go func() {
-			if msg != nil {
-				tTicker := time.NewTicker(time.Millisecond * 250)
+			tTicker := time.NewTicker(cfg.keepaliveInterval)
+			defer tTicker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-tTicker.C:
+				}
 
-				for _, value := range msg {
-					c.WriteMessage(websocket.TextMessage, *value)
-					<-tTicker.C
+				err := write(websocket.TextMessage, cfg.keepaliveMessage)
+				if err != nil {
+					logger.Log.Errorf("[%s] keepalive %s", cfg.endpoint, err.Error())
 				}
 			}
 		}()
+	}
 
-		for {
-			_, message, err := c.ReadMessage()
+	if pingInterval > 0 {
+		go func() {
+			tTicker := time.NewTicker(pingInterval)
+			defer tTicker.Stop()
 
-			if err != nil {
-				logger.Log.Errorf("[%s] %s", endpoint, err.Error())
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-tTicker.C:
+				}
+
+				err := write(websocket.PingMessage, nil)
+				if err != nil {
+					logger.Log.Errorf("[%s] ping %s", cfg.endpoint, err.Error())
+					continue
+				}
 
-				c = reconnect(c, m, endpoint)
-				if msg != nil {
-					tTicker := time.NewTicker(time.Millisecond * 250)
+				countEvent(cfg.statKey(), func(stat *WebsocketStat) { stat.PingsSent++ })
+			}
+		}()
+	}
 
-					for _, value := range msg {
-						c.WriteMessage(websocket.TextMessage, *value)
-						<-tTicker.C
-					}
+	if staleTimeout > 0 {
+		go func() {
+			tTicker := time.NewTicker(staleTimeout / 4)
+			defer tTicker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-tTicker.C:
+				}
+
+				last := time.Unix(0, atomic.LoadInt64(&lastMessage))
+				if time.Since(last) < staleTimeout {
+					continue
 				}
 
+				logger.Log.Errorf("[%s] no message since %s, force reconnect", cfg.endpoint, last.Format(time.RFC3339))
+				countEvent(cfg.statKey(), func(stat *WebsocketStat) { stat.StaleReconnects++ })
+
+				// Prevent closing again before reconnect
+				atomic.StoreInt64(&lastMessage, time.Now().UnixNano())
+				closeCurrent()
+			}
+		}()
+	}
+
+	log.Println("[websocket.go] websocketServe loop")
+	go subscribe(generation)
+
+	for {
+		messageType, message, err := c.ReadMessage()
+
+		if err != nil {
+			if ctx.Err() != nil {
+				log.Println("[websocket.go] websocketServe cancelled")
+				return nil
+			}
+
+			logger.Log.Errorf("[%s] %s", cfg.endpoint, err.Error())
+
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				countEvent(cfg.statKey(), func(stat *WebsocketStat) { stat.ReadTimeouts++ })
+			}
+			countEvent(cfg.statKey(), func(stat *WebsocketStat) { stat.Reconnects++ })
+
+			conn, err := reconnect(ctx, c, m, cfg)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+
+				// Retry policy exhausted, stop serving this endpoint
+				errHandler(err)
+				return err
+			}
+			prepare(conn)
+
+			writeLock.Lock()
+			c = conn
+			writeLock.Unlock()
+
+			// Cancelled while reconnecting, watcher already closed previous connection
+			if ctx.Err() != nil {
+				conn.Close()
+				return nil
+			}
+
+			go subscribe(atomic.AddInt64(&generation, 1))
+
+			if cfg.resync != nil {
+				cfg.resync()
+			}
+
+			continue
+		}
+
+		atomic.StoreInt64(&lastMessage, time.Now().UnixNano())
+		if readTimeout > 0 {
+			c.SetReadDeadline(time.Now().Add(readTimeout))
+		}
+
+		if cfg.decoder != nil {
+			message, err = cfg.decoder(messageType, message)
+			if err != nil {
+				errHandler(err)
 				continue
 			}
+		}
 
-			handler(message)
+		if cfg.protocol != nil && cfg.protocol(message, write) {
+			continue
 		}
-	}()
-	return
+
+		handler(message)
+	}
 }
 
-func connect(endpoint string) (conn *websocket.Conn) {
+// durationEnv returns environment value in second, defaultValue if not set or invalid
+func durationEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	sec, err := strconv.Atoi(value)
+	if err != nil || sec < 0 {
+		return defaultValue
+	}
+
+	return time.Second * time.Duration(sec)
+}
+
+// connect dial until success, falls back to polling cfg.resync
+// after pollAfter failed attempts until websocket is connected
+func connect(ctx context.Context, cfg *wsConfig) (*websocket.Conn, error) {
 	log.Println("[websocket.go] connect")
 
-	var err error
-	for {
-		conn, _, err = websocket.DefaultDialer.Dial(endpoint, nil)
+	var stopPolling func()
+	defer func() {
+		if stopPolling != nil {
+			stopPolling()
+		}
+	}()
 
-		if err != nil {
-			logger.Log.Error("Dial failed, retry 10 second")
+	policy := utils.DefaultRetryPolicy().WithOnRetry(func(attempt int, delay time.Duration, err error) {
+		logger.Log.Errorf("[%s] dial attempt %d failed, retry after %s : %s", cfg.endpoint, attempt, delay, err.Error())
 
-			time.Sleep(time.Second * time.Duration(reconnectInterval))
-		} else {
-			break
+		if cfg.resync != nil && pollAfter > 0 && attempt == pollAfter {
+			stopPolling = startPolling(ctx, cfg)
 		}
+	})
+
+	var conn *websocket.Conn
+	err := policy.RetryContext(ctx, func() (err error) {
+		conn, _, err = websocket.DefaultDialer.DialContext(ctx, cfg.endpoint, nil)
+		return err
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	countEvent(cfg.statKey(), func(stat *WebsocketStat) { stat.Mode = modeStreaming })
 	log.Println("[websocket.go] connect close")
 
-	return conn
+	return conn, nil
+}
+
+// startPolling poll cfg.resync until returned stop is called,
+// stop waits running resync so it does not race with websocket handler
+func startPolling(ctx context.Context, cfg *wsConfig) (stop func()) {
+	logger.Log.Errorf("[%s] websocket unavailable, switch to %s mode", cfg.statKey(), modePolling)
+	countEvent(cfg.statKey(), func(stat *WebsocketStat) { stat.Mode = modePolling })
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		tTicker := time.NewTicker(pollInterval)
+		defer tTicker.Stop()
+
+		for {
+			cfg.resync()
+			countEvent(cfg.statKey(), func(stat *WebsocketStat) { stat.Polls++ })
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-tTicker.C:
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+
+		// Printed regardless of log level, pairs with error of switching to polling
+		logger.Log.Printf("[%s] switch back to %s mode", cfg.statKey(), modeStreaming)
+	}
 }
 
-func reconnect(c *websocket.Conn, m *sync.Mutex, endpoint string) *websocket.Conn {
+func reconnect(ctx context.Context, c *websocket.Conn, m *sync.Mutex, cfg *wsConfig) (*websocket.Conn, error) {
 	log.Println("[websocket.go] reconnect")
 
 	m.Lock()
 	defer m.Unlock()
 
 	c.Close()
-	return connect(endpoint)
+	return connect(ctx, cfg)
 }
@@ -1,6 +1,7 @@
 package goredis
 
 import (
+	"encoding/json"
 	"errors"
 	"os"
 	"time"
@@ -8,20 +9,35 @@ import (
 	"github.com/gomodule/redigo/redis"
 	"github.com/jeongpope/go-crix/logger"
 	"github.com/jeongpope/go-crix/model"
+	"github.com/jeongpope/go-crix/utils"
 )
 
 var instance *stRedis
 
+const (
+	tickerKey    = "CRIX"
+	orderBookKey = "CRIX:ORDERBOOK"
+	tradeKey     = "CRIX:TRADE"
+	marketKey    = "CRIX:MARKET"
+	indexKey     = "CRIX:INDEX"
+)
+
 type stRedis struct {
-	pool       *redis.Pool
-	chanTicker chan model.Ticker
+	pool          *redis.Pool
+	chanTicker    chan model.Ticker
+	chanOrderBook chan model.OrderBook
+	chanTrade     chan model.Trade
+	chanMarket    chan model.MarketEvent
+	chanIndex     chan model.IndexValue
 
 	// Environment
-	host      string
-	port      string
-	dbNumber  string
-	maxIdle   int
-	maxActive int
+	host         string
+	port         string
+	dbNumber     string
+	maxIdle      int
+	maxActive    int
+	orderBookLen int // orderbook list is trimmed to latest snapshots
+	tradeLen     int // trade list is trimmed to latest trades
 }
 
 func GetInstance() *stRedis {
@@ -42,6 +58,22 @@ func (i *stRedis) GetTickerChannel() chan model.Ticker {
 	return i.chanTicker
 }
 
+func (i *stRedis) GetOrderBookChannel() chan model.OrderBook {
+	return i.chanOrderBook
+}
+
+func (i *stRedis) GetTradeChannel() chan model.Trade {
+	return i.chanTrade
+}
+
+func (i *stRedis) GetMarketChannel() chan model.MarketEvent {
+	return i.chanMarket
+}
+
+func (i *stRedis) GetIndexChannel() chan model.IndexValue {
+	return i.chanIndex
+}
+
 func initialize() error {
 	logger.Log.Info("[redis.go] Start initialze()")
 
@@ -52,6 +84,10 @@ func initialize() error {
 	instance.dbNumber = os.Getenv("REDIS_DB_NUMBER")
 	instance.maxIdle = 80
 	instance.maxActive = 12000
+	// REDIS_ORDERBOOK_LEN : orderbook snapshots kept in list, 0 disables trim
+	instance.orderBookLen = utils.IntEnv("REDIS_ORDERBOOK_LEN", 1000)
+	// REDIS_TRADE_LEN : trades kept in list, 0 disables trim
+	instance.tradeLen = utils.IntEnv("REDIS_TRADE_LEN", 10000)
 
 	instance.pool = &redis.Pool{
 		MaxIdle:   instance.maxIdle,
@@ -67,6 +103,10 @@ func initialize() error {
 	}
 
 	instance.chanTicker = make(chan model.Ticker)
+	instance.chanOrderBook = make(chan model.OrderBook, 512)
+	instance.chanTrade = make(chan model.Trade, 512)
+	instance.chanMarket = make(chan model.MarketEvent, 64)
+	instance.chanIndex = make(chan model.IndexValue, 512)
 
 	logger.Log.Info("[redis.go] End Initialze()")
 	return nil
@@ -81,40 +121,125 @@ func ping(conn redis.Conn) error {
 	return nil
 }
 
+// getConn returns pooled connection passed ping,
+// retried by utils.DefaultRetryPolicy without attempt limit
+func getConn() (conn redis.Conn) {
+	policy := utils.DefaultRetryPolicy().WithOnRetry(func(attempt int, delay time.Duration, err error) {
+		logger.Log.Errorf("Redis ping attempt %d failed, retry after %s : %s", attempt, delay, err.Error())
+	})
+	policy.MaxAttempts = 0
+
+	policy.Retry(func() error {
+		conn = instance.pool.Get()
+
+		err := ping(conn)
+		if err != nil {
+			conn.Close()
+		}
+		return err
+	})
+
+	return conn
+}
+
+// push select db and push message to the tail of key
+func push(conn redis.Conn, key string, msg interface{}) error {
+	_, err := redis.String(conn.Do("SELECT", instance.dbNumber))
+	if err != nil {
+		return errors.New("Failed select db, " + err.Error())
+	}
+
+	_, err = redis.Int64(conn.Do("RPUSH", key, msg))
+	if err != nil {
+		return errors.New("Failed RPUSH " + key + ", " + err.Error())
+	}
+
+	return nil
+}
+
+// trim keep latest maxLen messages of key
+func trim(conn redis.Conn, key string, maxLen int) error {
+	_, err := redis.String(conn.Do("LTRIM", key, -maxLen, -1))
+	if err != nil {
+		return errors.New("Failed LTRIM " + key + ", " + err.Error())
+	}
+
+	return nil
+}
+
 func (i *stRedis) Update() {
 	logger.Log.Info("[redis.go] Start Update()")
 
 	go func() {
 		for {
-			var conn redis.Conn
+			conn := getConn()
 
+		receive:
 			for {
-				conn = instance.pool.Get()
-				err := ping(conn)
-				if err != nil {
-					logger.Log.Errorf(err.Error())
-					time.Sleep(time.Second * 5)
-				} else {
-					break
-				}
-			}
+				var key string
+				var msg interface{}
+				var maxLen int
 
-			for {
-				if msg, openChannel := <-instance.chanTicker; openChannel {
-					_, err := redis.String(conn.Do("SELECT", instance.dbNumber))
-					if err != nil {
-						logger.Log.Errorf("Failed select db, %s", err.Error())
-						continue
+				select {
+				case ticker, openChannel := <-instance.chanTicker:
+					if !openChannel {
+						logger.Log.Info("Redis receive channel is closed.")
+						break receive
+					}
+
+					// JSON like other payloads, fmt layout changed whenever model.Ticker got a field
+					jsonBytes, _ := json.Marshal(ticker)
+					key, msg = tickerKey, jsonBytes
+				case ob, openChannel := <-instance.chanOrderBook:
+					if !openChannel {
+						logger.Log.Info("Redis orderbook channel is closed.")
+						break receive
+					}
+
+					jsonBytes, _ := json.Marshal(ob)
+					// Orderbook is updated many times per second, list keeps latest snapshots only
+					key, msg, maxLen = orderBookKey, jsonBytes, instance.orderBookLen
+				case trade, openChannel := <-instance.chanTrade:
+					if !openChannel {
+						logger.Log.Info("Redis trade channel is closed.")
+						break receive
+					}
+
+					jsonBytes, _ := json.Marshal(trade)
+					key, msg, maxLen = tradeKey, jsonBytes, instance.tradeLen
+				case event, openChannel := <-instance.chanMarket:
+					if !openChannel {
+						logger.Log.Info("Redis market channel is closed.")
+						break receive
 					}
 
-					_, err = redis.Int64(conn.Do("RPUSH", "CRIX", msg))
+					jsonBytes, _ := json.Marshal(event)
+					key, msg = marketKey, jsonBytes
+				case value, openChannel := <-instance.chanIndex:
+					if !openChannel {
+						logger.Log.Info("Redis index channel is closed.")
+						break receive
+					}
+
+					// Each index family has own list
+					jsonBytes, _ := json.Marshal(value)
+					key, msg = indexKey, jsonBytes
+					if value.Key != "" {
+						key = indexKey + ":" + value.Key
+					}
+				}
+
+				err := push(conn, key, msg)
+				if err != nil {
+					logger.Log.Errorf("Failed push message, %s", err.Error())
+					continue
+				}
+
+				if maxLen > 0 {
+					err = trim(conn, key, maxLen)
 					if err != nil {
-						logger.Log.Errorf("Failed ticker push message, %s", err.Error())
-						continue
+						logger.Log.Errorf("Failed trim message, %s", err.Error())
 					}
-				} else {
-					logger.Log.Info("Redis receive channel is closed.")
-					break
 				}
 			}
 
@@ -129,20 +254,10 @@ func (i *stRedis) Update() {
 		for {
 			<-ticker.C
 
-			var conn redis.Conn
-
-			for {
-				conn = instance.pool.Get()
-				err := ping(conn)
-				if err != nil {
-					logger.Log.Errorf(err.Error())
-					time.Sleep(time.Second * 5)
-				} else {
-					break
-				}
-			}
+			conn := getConn()
 
 			//conn.Do("flushall")
+			conn.Close()
 		}
 	}()
 }
@@ -150,4 +265,8 @@ func (i *stRedis) Update() {
 func (i *stRedis) Release() {
 	instance.pool.Close()
 	close(instance.chanTicker)
+	close(instance.chanOrderBook)
+	close(instance.chanTrade)
+	close(instance.chanMarket)
+	close(instance.chanIndex)
 }
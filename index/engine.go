@@ -0,0 +1,446 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/jeongpope/go-crix/logger"
+	"github.com/jeongpope/go-crix/model"
+)
+
+var (
+	ErrNoPrice         = errors.New("constituent has no price")
+	ErrNoRebalanceRule = errors.New("index has no rebalance rule")
+	ErrConfigChanged   = errors.New("index config differs from divisor history")
+	ErrNoCandidate     = errors.New("no candidate has price and volume history")
+)
+
+// Index calculate market-cap or equal weighted level of constituents
+//
+// level = sum(price x supply) / divisor
+// divisor is set when every constituent has price for the first time after base date, so level starts at base value,
+// and adjusted on every membership change so level is continuous.
+// equal weight index set supply to K / price on every (re)constitution, so every constituent has same weight
+type Index struct {
+	def     Definition
+	history *divisorHistory // optional, persists divisor adjustments
+	volumes *volumeHistory  // optional, daily trade price for rebalance
+
+	lock     *sync.Mutex
+	prices   map[string]float64 // currency : price, every currency of exchange and quote
+	warnings map[string]string  // currency : market warning
+	supplies map[string]float64 // currency : supply, constituents
+	divisor  float64
+
+	rebalanced string // last rebalance period (ex. 2024-03, 2024-Q1)
+	previewed  bool   // rebalance checked since start
+}
+
+func newIndex(def Definition, history *divisorHistory) *Index {
+	ix := &Index{
+		def:      def,
+		history:  history,
+		lock:     &sync.Mutex{},
+		prices:   make(map[string]float64),
+		warnings: make(map[string]string),
+		supplies: make(map[string]float64),
+	}
+
+	for _, v := range def.Constituents {
+		ix.supplies[v.Currency] = v.Supply
+	}
+
+	return ix
+}
+
+// restore continue from last adjustment, divisor and constituents are kept across restarts.
+// error if constituents of config file are edited since history was written,
+// history would silently override them
+func (ix *Index) restore(record model.DivisorAdjustment) error {
+	ix.lock.Lock()
+	defer ix.lock.Unlock()
+
+	config := configSupplies(ix.def)
+	if record.Config != nil && !reflect.DeepEqual(record.Config, config) {
+		return fmt.Errorf("%w, %s config %v, history %v : revert config, or remove records of %s from divisor history to restart at base value",
+			ErrConfigChanged, ix.def.Name, config, record.Config, ix.def.Name)
+	}
+
+	ix.divisor = record.NewDivisor
+	ix.supplies = make(map[string]float64, len(record.Constituents))
+	for k, v := range record.Constituents {
+		ix.supplies[k] = v
+	}
+
+	logger.Log.Infof("[engine.go] %s restored divisor %f, %d constituents", ix.def.Name, ix.divisor, len(ix.supplies))
+	return nil
+}
+
+// configSupplies returns constituents of config file, currency : supply
+func configSupplies(def Definition) map[string]float64 {
+	supplies := make(map[string]float64, len(def.Constituents))
+	for _, v := range def.Constituents {
+		supplies[v.Currency] = v.Supply
+	}
+
+	return supplies
+}
+
+// update apply ticker price, returns index value if ticker is price of constituent
+// and every constituent has price
+func (ix *Index) update(ticker model.Ticker, now time.Time) (model.IndexValue, bool) {
+	if ticker.Exchange != ix.def.Exchange || (ix.def.Quote != "" && ticker.Quote != ix.def.Quote) {
+		return model.IndexValue{}, false
+	}
+
+	ix.lock.Lock()
+	defer ix.lock.Unlock()
+
+	if ticker.MarketWarning != "" {
+		ix.warnings[ticker.Currency] = ticker.MarketWarning
+	}
+
+	if ix.volumes != nil {
+		err := ix.volumes.add(volumeKey(ix.def.Exchange, ix.def.Quote, ticker.Currency), float64(ticker.Volume), now)
+		if err != nil {
+			logger.Log.Errorf("%s failed to save volume history : %s", ix.def.Name, err.Error())
+		}
+	}
+
+	if ix.prices[ticker.Currency] == ticker.Price {
+		return model.IndexValue{}, false
+	}
+	ix.prices[ticker.Currency] = ticker.Price
+
+	if _, ok := ix.supplies[ticker.Currency]; !ok {
+		return model.IndexValue{}, false
+	}
+
+	if ix.divisor == 0 && now.Before(ix.def.baseDate) {
+		return model.IndexValue{}, false
+	}
+
+	// Equal weight is set at base value, so divisor starts at 1
+	if ix.divisor == 0 && ix.def.Methodology == methodologyEqual {
+		supplies, err := ix.equalWeights(ix.supplies, ix.def.BaseValue)
+		if err != nil {
+			return model.IndexValue{}, false
+		}
+		ix.supplies = supplies
+	}
+
+	marketCap, err := ix.marketCap(ix.supplies)
+	if err != nil {
+		return model.IndexValue{}, false
+	}
+
+	if ix.divisor == 0 {
+		ix.divisor = marketCap / ix.def.BaseValue
+
+		ix.record(model.DivisorAdjustment{
+			Reason:     model.DivisorInitial,
+			NewDivisor: ix.divisor,
+			Level:      ix.def.BaseValue,
+			Added:      sortedKeys(ix.supplies),
+		}, now)
+	}
+
+	return ix.value(marketCap, now), true
+}
+
+// setConstituents replace constituents and adjust divisor so level does not change,
+// every new constituent must have price
+func (ix *Index) setConstituents(supplies map[string]float64, reason string, now time.Time) (model.DivisorAdjustment, error) {
+	ix.lock.Lock()
+	defer ix.lock.Unlock()
+
+	// Equal weight keeps market cap, so divisor does not change
+	if ix.def.Methodology == methodologyEqual && ix.divisor != 0 {
+		marketCap, err := ix.marketCap(ix.supplies)
+		if err != nil {
+			return model.DivisorAdjustment{Reason: reason}, err
+		}
+
+		supplies, err = ix.equalWeights(supplies, marketCap)
+		if err != nil {
+			return model.DivisorAdjustment{Reason: reason}, err
+		}
+	}
+
+	record := model.DivisorAdjustment{Reason: reason, OldDivisor: ix.divisor, NewDivisor: ix.divisor}
+	for k, v := range supplies {
+		old, ok := ix.supplies[k]
+		switch {
+		case !ok:
+			record.Added = append(record.Added, k)
+		case old != v:
+			record.Reweighted = append(record.Reweighted, k)
+		}
+	}
+	for k := range ix.supplies {
+		if _, ok := supplies[k]; !ok {
+			record.Removed = append(record.Removed, k)
+		}
+	}
+	sort.Strings(record.Added)
+	sort.Strings(record.Removed)
+	sort.Strings(record.Reweighted)
+
+	if len(record.Added) == 0 && len(record.Removed) == 0 && len(record.Reweighted) == 0 {
+		return record, nil
+	}
+
+	// Divisor is not set yet, it is initialized by new constituents
+	if ix.divisor != 0 {
+		oldCap, err := ix.marketCap(ix.supplies)
+		if err != nil {
+			return record, err
+		}
+
+		newCap, err := ix.marketCap(supplies)
+		if err != nil {
+			return record, err
+		}
+
+		record.Level = oldCap / ix.divisor
+		record.NewDivisor = ix.divisor * newCap / oldCap
+	}
+
+	ix.supplies = make(map[string]float64, len(supplies))
+	for k, v := range supplies {
+		ix.supplies[k] = v
+	}
+	ix.divisor = record.NewDivisor
+
+	return ix.record(record, now), nil
+}
+
+// rebalanceIfDue select constituents by rule once per schedule period,
+// first period after start is skipped if there is no previous rebalance.
+// dry-run writes no history, so its proposal is also printed at first check after start
+func (ix *Index) rebalanceIfDue(now time.Time) {
+	rule := ix.def.Rebalance
+	if rule == nil {
+		return
+	}
+
+	ix.lock.Lock()
+	key := periodKey(rule.Schedule, now)
+	due := ix.rebalanced != "" && ix.rebalanced != key
+	if ix.rebalanced == "" || due {
+		ix.rebalanced = key
+	}
+	if rule.DryRun && !ix.previewed {
+		due = true
+	}
+	ix.previewed = true
+	ix.lock.Unlock()
+
+	if !due {
+		return
+	}
+
+	p, err := ix.preview(now, rule.DryRun)
+	if errors.Is(err, ErrNoCandidate) {
+		// Volume history is empty at first start, constituents are kept until next period
+		logger.Log.Printf("[engine.go] %s rebalance skipped, constituents kept : %s", ix.def.Name, err.Error())
+		return
+	}
+	if err != nil {
+		logger.Log.Errorf("%s rebalance failed : %s", ix.def.Name, err.Error())
+		return
+	}
+
+	if rule.DryRun {
+		return
+	}
+
+	_, err = ix.setConstituents(p.supplies, model.DivisorRebalance, now)
+	if err != nil {
+		logger.Log.Errorf("%s rebalance failed : %s", ix.def.Name, err.Error())
+	}
+}
+
+// preview print proposal of rule without changing constituents
+func (ix *Index) preview(now time.Time, dryRun bool) (*proposal, error) {
+	if ix.def.Rebalance == nil {
+		return nil, ErrNoRebalanceRule
+	}
+
+	p, err := ix.propose(now)
+	if err != nil {
+		return nil, err
+	}
+
+	printProposal(ix.def.Name, p, dryRun)
+	return p, nil
+}
+
+// propose returns constituents selected by rule and turnover against current constituents
+func (ix *Index) propose(now time.Time) (*proposal, error) {
+	rule := ix.def.Rebalance
+
+	ix.lock.Lock()
+	defer ix.lock.Unlock()
+
+	var candidates []candidate
+	var noPrice, noVolume int
+	for currency, supply := range rule.Supplies {
+		c := candidate{
+			currency: currency,
+			price:    ix.prices[currency],
+			supply:   supply,
+			warning:  ix.warnings[currency],
+		}
+		if ix.volumes != nil {
+			c.volume, _ = ix.volumes.average(volumeKey(ix.def.Exchange, ix.def.Quote, currency), rule.VolumeDays, now)
+		}
+		if c.price <= 0 {
+			noPrice++
+		}
+		if c.volume <= 0 {
+			noVolume++
+		}
+
+		candidates = append(candidates, c)
+	}
+
+	supplies, err := selectConstituents(rule, candidates)
+	if err != nil {
+		return nil, err
+	}
+	if len(supplies) == 0 {
+		return nil, fmt.Errorf("%w, %d candidates, %d without price, %d without volume history",
+			ErrNoCandidate, len(candidates), noPrice, noVolume)
+	}
+
+	p := &proposal{supplies: supplies}
+	p.oldWeights = weightsOf(ix.supplies, ix.prices)
+	p.newWeights = weightsOf(p.supplies, ix.prices)
+	if ix.def.Methodology == methodologyEqual {
+		for k := range p.newWeights {
+			p.newWeights[k] = 1 / float64(len(p.newWeights))
+		}
+	}
+	p.turnover = turnover(p.oldWeights, p.newWeights)
+
+	return p, nil
+}
+
+// constituents returns copy of constituent supplies
+func (ix *Index) constituents() map[string]float64 {
+	ix.lock.Lock()
+	defer ix.lock.Unlock()
+
+	supplies := make(map[string]float64, len(ix.supplies))
+	for k, v := range ix.supplies {
+		supplies[k] = v
+	}
+
+	return supplies
+}
+
+// record complete adjustment, persist and log audit record, lock must be held
+func (ix *Index) record(record model.DivisorAdjustment, now time.Time) model.DivisorAdjustment {
+	record.Name = ix.def.Name
+	record.Timestamp = now.UnixNano() / int64(time.Millisecond)
+	record.Constituents = make(map[string]float64, len(ix.supplies))
+	for k, v := range ix.supplies {
+		record.Constituents[k] = v
+	}
+	record.Config = configSupplies(ix.def)
+
+	// Audit record is printed regardless of log level
+	logger.Log.Printf("[AUDIT] %s divisor %s %f -> %f, level %f, added %v, removed %v, reweighted %v",
+		record.Name, record.Reason, record.OldDivisor, record.NewDivisor, record.Level,
+		record.Added, record.Removed, record.Reweighted)
+
+	if ix.history != nil {
+		err := ix.history.append(record)
+		if err != nil {
+			logger.Log.Errorf("%s failed to persist divisor adjustment : %s", ix.def.Name, err.Error())
+		}
+	}
+
+	return record
+}
+
+// marketCap returns total market cap of supplies, error if any constituent has no price
+func (ix *Index) marketCap(supplies map[string]float64) (float64, error) {
+	var total float64
+	for k, v := range supplies {
+		price := ix.prices[k]
+		if price <= 0 {
+			return 0, fmt.Errorf("%w, %s", ErrNoPrice, k)
+		}
+
+		total += price * v
+	}
+
+	if total <= 0 {
+		return 0, ErrNoPrice
+	}
+
+	return total, nil
+}
+
+// equalWeights returns supplies of same market cap, total / count each, lock must be held
+func (ix *Index) equalWeights(supplies map[string]float64, total float64) (map[string]float64, error) {
+	weighted := make(map[string]float64, len(supplies))
+	for k := range supplies {
+		price := ix.prices[k]
+		if price <= 0 {
+			return nil, fmt.Errorf("%w, %s", ErrNoPrice, k)
+		}
+
+		weighted[k] = total / float64(len(supplies)) / price
+	}
+
+	return weighted, nil
+}
+
+func (ix *Index) value(marketCap float64, now time.Time) model.IndexValue {
+	value := model.IndexValue{
+		Name:         ix.def.Name,
+		Key:          ix.def.Key,
+		Level:        marketCap / ix.divisor,
+		Divisor:      ix.divisor,
+		Constituents: make([]model.IndexConstituent, 0, len(ix.supplies)),
+		Timestamp:    now.UnixNano() / int64(time.Millisecond),
+	}
+
+	for currency, supply := range ix.supplies {
+		price := ix.prices[currency]
+		capital := price * supply
+
+		value.Constituents = append(value.Constituents, model.IndexConstituent{
+			Currency:     currency,
+			Price:        price,
+			Supply:       supply,
+			MarketCap:    capital,
+			Weight:       capital / marketCap,
+			Contribution: capital / ix.divisor,
+		})
+	}
+
+	// Largest contribution first
+	sort.Slice(value.Constituents, func(i, j int) bool {
+		return value.Constituents[i].MarketCap > value.Constituents[j].MarketCap
+	})
+
+	return value
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
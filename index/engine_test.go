@@ -0,0 +1,175 @@
+package index
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jeongpope/go-crix/model"
+)
+
+func Test_IndexUpdate(t *testing.T) {
+	ix := newIndex(Definition{
+		Name:      "CRIX",
+		Exchange:  "UPBIT",
+		Quote:     "KRW",
+		BaseValue: 1000,
+		Constituents: []ConstituentDefinition{
+			{Currency: "BTC", Supply: 1},
+			{Currency: "ETH", Supply: 10},
+		},
+	}, nil)
+	now := time.Now()
+
+	if _, ok := ix.update(model.Ticker{Exchange: "UPBIT", Currency: "BTC", Quote: "KRW", Price: 100}, now); ok {
+		t.Fatal("expected no value before every constituent has price")
+	}
+
+	// Other quote is ignored
+	if _, ok := ix.update(model.Ticker{Exchange: "UPBIT", Currency: "ETH", Quote: "BTC", Price: 1}, now); ok {
+		t.Fatal("expected ticker of other quote ignored")
+	}
+
+	value, ok := ix.update(model.Ticker{Exchange: "UPBIT", Currency: "ETH", Quote: "KRW", Price: 10}, now)
+	if !ok || value.Level != 1000 {
+		t.Fatalf("base level = %f, %t", value.Level, ok)
+	}
+
+	// BTC 100 -> 150, market cap 200 -> 250
+	value, _ = ix.update(model.Ticker{Exchange: "UPBIT", Currency: "BTC", Quote: "KRW", Price: 150}, now)
+	if math.Abs(value.Level-1250) > 1e-9 {
+		t.Errorf("level = %f, expected 1250", value.Level)
+	}
+
+	if value.Constituents[0].Currency != "BTC" || math.Abs(value.Constituents[0].Weight-0.6) > 1e-9 {
+		t.Errorf("unexpected constituents %v", value.Constituents)
+	}
+}
+
+func Test_IndexDivisorAdjustment(t *testing.T) {
+	history := newDivisorHistory(filepath.Join(t.TempDir(), "history.jsonl"))
+	ix := newIndex(Definition{
+		Name:         "CRIX",
+		Exchange:     "UPBIT",
+		BaseValue:    1000,
+		Constituents: []ConstituentDefinition{{Currency: "BTC", Supply: 1}},
+	}, history)
+	now := time.Now()
+
+	ix.update(model.Ticker{Exchange: "UPBIT", Currency: "ETH", Price: 10}, now)
+	ix.update(model.Ticker{Exchange: "UPBIT", Currency: "BTC", Price: 100}, now)
+	value, _ := ix.update(model.Ticker{Exchange: "UPBIT", Currency: "BTC", Price: 200}, now)
+
+	// Add ETH, market cap 200 -> 300 while level stays 2000
+	record, err := ix.setConstituents(map[string]float64{"BTC": 1, "ETH": 10}, model.DivisorManual, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(record.Level-value.Level) > 1e-9 || math.Abs(record.NewDivisor-0.15) > 1e-9 {
+		t.Errorf("unexpected adjustment %+v", record)
+	}
+
+	value, _ = ix.update(model.Ticker{Exchange: "UPBIT", Currency: "ETH", Price: 20}, now)
+	if math.Abs(value.Level-400/0.15) > 1e-9 {
+		t.Errorf("level = %f, expected %f", value.Level, 400/0.15)
+	}
+
+	// Constituent without price cannot be added
+	if _, err := ix.setConstituents(map[string]float64{"BTC": 1, "XRP": 1}, model.DivisorManual, now); !errors.Is(err, ErrNoPrice) {
+		t.Errorf("expected ErrNoPrice, got %v", err)
+	}
+
+	last, ok, err := history.last("CRIX", "")
+	if err != nil || !ok || last.NewDivisor != record.NewDivisor || last.Constituents["ETH"] != 10 {
+		t.Errorf("unexpected last history %+v, %t, %v", last, ok, err)
+	}
+}
+
+func Test_IndexEqualWeight(t *testing.T) {
+	ix := newIndex(Definition{
+		Name:         "CRIX-EW",
+		Exchange:     "UPBIT",
+		Methodology:  methodologyEqual,
+		BaseValue:    100,
+		Constituents: []ConstituentDefinition{{Currency: "BTC"}, {Currency: "ETH"}},
+	}, nil)
+	now := time.Now()
+
+	ix.update(model.Ticker{Exchange: "UPBIT", Currency: "BTC", Price: 100}, now)
+	value, ok := ix.update(model.Ticker{Exchange: "UPBIT", Currency: "ETH", Price: 10}, now)
+	if !ok || math.Abs(value.Level-100) > 1e-9 || math.Abs(value.Constituents[0].Weight-0.5) > 1e-9 {
+		t.Fatalf("unexpected base value %+v, %t", value, ok)
+	}
+
+	// BTC doubles, level 100 -> 150
+	value, _ = ix.update(model.Ticker{Exchange: "UPBIT", Currency: "BTC", Price: 200}, now)
+	if math.Abs(value.Level-150) > 1e-9 {
+		t.Errorf("level = %f, expected 150", value.Level)
+	}
+
+	// Reconstitution resets weights without divisor change
+	ix.update(model.Ticker{Exchange: "UPBIT", Currency: "XRP", Price: 1}, now)
+	record, err := ix.setConstituents(map[string]float64{"BTC": 0, "ETH": 0, "XRP": 0}, model.DivisorManual, now)
+	if err != nil || record.NewDivisor != record.OldDivisor {
+		t.Fatalf("unexpected adjustment %+v, %v", record, err)
+	}
+
+	if supplies := ix.constituents(); math.Abs(supplies["XRP"]-50) > 1e-9 || math.Abs(supplies["BTC"]-0.25) > 1e-9 {
+		t.Errorf("unexpected supplies %v", supplies)
+	}
+}
+
+func Test_IndexBaseDate(t *testing.T) {
+	def := Definition{
+		Name:         "CRIX",
+		Exchange:     "UPBIT",
+		BaseDate:     "2024-01-01",
+		Constituents: []ConstituentDefinition{{Currency: "BTC", Supply: 1}},
+	}
+	if err := normalizeDefinition(&def); err != nil {
+		t.Fatal(err)
+	}
+	ix := newIndex(def, nil)
+
+	// 2023-12-31 23:00 KST is before base date
+	before := time.Date(2023, 12, 31, 14, 0, 0, 0, time.UTC)
+	if _, ok := ix.update(model.Ticker{Exchange: "UPBIT", Currency: "BTC", Price: 100}, before); ok {
+		t.Fatal("expected no value before base date")
+	}
+
+	value, ok := ix.update(model.Ticker{Exchange: "UPBIT", Currency: "BTC", Price: 200}, before.Add(2*time.Hour))
+	if !ok || value.Level != 1000 || value.Key != "CRIX" {
+		t.Errorf("unexpected value %+v, %t", value, ok)
+	}
+}
+
+func Test_IndexRestoreConfigChanged(t *testing.T) {
+	def := Definition{
+		Name:         "CRIX",
+		Exchange:     "UPBIT",
+		BaseValue:    1000,
+		Constituents: []ConstituentDefinition{{Currency: "BTC", Supply: 1}},
+	}
+	history := newDivisorHistory(filepath.Join(t.TempDir(), "history.jsonl"))
+	ix := newIndex(def, history)
+	now := time.Now()
+
+	ix.update(model.Ticker{Exchange: "UPBIT", Currency: "BTC", Price: 100}, now)
+	ix.update(model.Ticker{Exchange: "UPBIT", Currency: "ETH", Price: 10}, now)
+	ix.setConstituents(map[string]float64{"BTC": 1, "ETH": 10}, model.DivisorManual, now)
+
+	// Constituents changed at runtime are restored with unchanged config
+	record, _, _ := history.last("CRIX", "")
+	restored := newIndex(def, history)
+	if err := restored.restore(record); err != nil || len(restored.constituents()) != 2 {
+		t.Fatalf("unexpected restore %v, %v", restored.constituents(), err)
+	}
+
+	def.Constituents = append(def.Constituents, ConstituentDefinition{Currency: "XRP", Supply: 100})
+	if err := newIndex(def, history).restore(record); !errors.Is(err, ErrConfigChanged) {
+		t.Errorf("expected ErrConfigChanged, got %v", err)
+	}
+}
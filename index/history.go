@@ -0,0 +1,78 @@
+package index
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/jeongpope/go-crix/model"
+)
+
+// divisorHistory persists divisor adjustments as JSON lines, one record per adjustment
+type divisorHistory struct {
+	path string
+	lock *sync.Mutex
+}
+
+func newDivisorHistory(path string) *divisorHistory {
+	return &divisorHistory{path: path, lock: &sync.Mutex{}}
+}
+
+func (h *divisorHistory) append(record model.DivisorAdjustment) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(data, '\n'))
+	if err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
+
+// last returns last adjustment of index, reason filters adjustments if not empty
+func (h *divisorHistory) last(name string, reason string) (model.DivisorAdjustment, bool, error) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	var last model.DivisorAdjustment
+	var found bool
+
+	f, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return last, false, nil
+	}
+	if err != nil {
+		return last, false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var record model.DivisorAdjustment
+		if json.Unmarshal(scanner.Bytes(), &record) != nil || record.Name != name {
+			continue
+		}
+
+		if reason != "" && record.Reason != reason {
+			continue
+		}
+
+		last, found = record, true
+	}
+
+	return last, found, scanner.Err()
+}
@@ -0,0 +1,365 @@
+package index
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jeongpope/go-crix/goredis"
+	"github.com/jeongpope/go-crix/logger"
+	"github.com/jeongpope/go-crix/model"
+)
+
+var instance *stIndex
+
+var (
+	ErrInvalidDefinition = errors.New("invalid index definition")
+	ErrUnknownIndex      = errors.New("unknown index")
+)
+
+const (
+	defaultBaseValue   = 1000
+	defaultHistoryPath = "index/divisor_history.jsonl" // GOCRIX_INDEX_HISTORY : divisor adjustment history file
+	defaultVolumePath  = "index/volume_history.json"   // GOCRIX_INDEX_VOLUME_HISTORY : daily trade price history file
+
+	rebalanceCheckInterval = time.Minute
+
+	methodologyCap   = "cap"
+	methodologyEqual = "equal"
+)
+
+type stIndex struct {
+	indexes   []*Index              // empty if GOCRIX_INDEX_CONFIG is not set
+	chanIndex chan model.IndexValue // to send index value channel
+}
+
+// Definition define index configuration, several definitions run side by side off same tickers
+//
+// GOCRIX_INDEX_CONFIG : JSON file path of Definition or array of Definition (see index.example.json)
+type Definition struct {
+	Name         string                  `json:"name"`        // index name (ex. CRIX)
+	Key          string                  `json:"key"`         // output key, redis CRIX:INDEX:<key> and amqp queue, default name
+	Methodology  string                  `json:"methodology"` // cap (default), equal
+	Exchange     string                  `json:"exchange"`    // price source exchange (ex. UPBIT)
+	Quote        string                  `json:"quote"`       // price quote asset (ex. KRW)
+	BaseDate     string                  `json:"base_date"`   // calculation starts at 00:00 KST of base date (2006-01-02), default now
+	BaseValue    float64                 `json:"base_value"`  // level when calculation starts, default 1000
+	Constituents []ConstituentDefinition `json:"constituents"`
+	Rebalance    *RebalanceRule          `json:"rebalance"` // optional, scheduled rule based selection
+
+	baseDate time.Time
+}
+
+// ConstituentDefinition define constituent and its supply used as weight
+type ConstituentDefinition struct {
+	Currency string  `json:"currency"` // currency code (ex. BTC)
+	Supply   float64 `json:"supply"`   // circulating supply, ignored by equal weight
+}
+
+func GetInstance() *stIndex {
+	if instance != nil {
+		return instance
+	}
+
+	instance = new(stIndex)
+	err := instance.initialize()
+	if err != nil {
+		logger.Log.Error("Failed to index instance initialize, check index config : ", err)
+		instance = nil
+		return nil
+	}
+
+	return instance
+}
+
+func (i *stIndex) initialize() error {
+	logger.Log.Info("[index.go] Start initialize()")
+
+	path := os.Getenv("GOCRIX_INDEX_CONFIG")
+	if path == "" {
+		logger.Log.Info("[index.go] GOCRIX_INDEX_CONFIG is not set, index disabled")
+		return nil
+	}
+
+	defs, err := loadDefinitions(path)
+	if err != nil {
+		return err
+	}
+
+	historyPath := os.Getenv("GOCRIX_INDEX_HISTORY")
+	if historyPath == "" {
+		historyPath = defaultHistoryPath
+	}
+	history := newDivisorHistory(historyPath)
+
+	// Daily volumes are shared by every index, kept for longest rule
+	var volumes *volumeHistory
+	for _, def := range defs {
+		if def.Rebalance == nil {
+			continue
+		}
+
+		if volumes == nil {
+			volumePath := os.Getenv("GOCRIX_INDEX_VOLUME_HISTORY")
+			if volumePath == "" {
+				volumePath = defaultVolumePath
+			}
+			volumes = newVolumeHistory(volumePath, def.Rebalance.VolumeDays)
+		}
+
+		if def.Rebalance.VolumeDays > volumes.days {
+			volumes.days = def.Rebalance.VolumeDays
+		}
+	}
+
+	if volumes != nil {
+		err = volumes.load()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, def := range defs {
+		ix := newIndex(def, history)
+
+		// Continue divisor and constituents of previous run
+		record, ok, err := history.last(def.Name, "")
+		if err != nil {
+			return err
+		}
+		if ok {
+			err = ix.restore(record)
+			if err != nil {
+				return err
+			}
+		}
+
+		if def.Rebalance != nil {
+			ix.volumes = volumes
+
+			record, ok, err = history.last(def.Name, model.DivisorRebalance)
+			if err != nil {
+				return err
+			}
+			if ok {
+				ix.rebalanced = periodKey(def.Rebalance.Schedule, time.Unix(0, record.Timestamp*int64(time.Millisecond)))
+			}
+		}
+
+		i.indexes = append(i.indexes, ix)
+		logger.Log.Infof("[index.go] %s (%s, %s) initialized, %d constituents", def.Name, def.Key, def.Methodology, len(def.Constituents))
+	}
+
+	i.chanIndex = goredis.GetInstance().GetIndexChannel()
+
+	logger.Log.Info("[index.go] End initialize()")
+	return nil
+}
+
+// loadDefinitions read single definition or array of definitions,
+// name and key must be unique
+func loadDefinitions(path string) ([]Definition, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var defs []Definition
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(data, &defs)
+	} else {
+		defs = make([]Definition, 1)
+		err = json.Unmarshal(data, &defs[0])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(defs) == 0 {
+		return nil, ErrInvalidDefinition
+	}
+
+	names := make(map[string]struct{}, len(defs))
+	keys := make(map[string]struct{}, len(defs))
+	for k := range defs {
+		err = normalizeDefinition(&defs[k])
+		if err != nil {
+			return nil, fmt.Errorf("%w, %s", err, defs[k].Name)
+		}
+
+		if _, ok := names[defs[k].Name]; ok {
+			return nil, fmt.Errorf("%w, duplicated name %s", ErrInvalidDefinition, defs[k].Name)
+		}
+		names[defs[k].Name] = struct{}{}
+
+		if _, ok := keys[defs[k].Key]; ok {
+			return nil, fmt.Errorf("%w, duplicated key %s", ErrInvalidDefinition, defs[k].Key)
+		}
+		keys[defs[k].Key] = struct{}{}
+	}
+
+	return defs, nil
+}
+
+func normalizeDefinition(def *Definition) error {
+	if def.Name == "" || def.Exchange == "" || len(def.Constituents) == 0 {
+		return ErrInvalidDefinition
+	}
+
+	if def.Key == "" {
+		def.Key = def.Name
+	}
+
+	switch def.Methodology {
+	case "":
+		def.Methodology = methodologyCap
+	case methodologyCap, methodologyEqual:
+	default:
+		return ErrInvalidDefinition
+	}
+
+	if def.BaseDate != "" {
+		baseDate, err := time.ParseInLocation("2006-01-02", def.BaseDate, kst)
+		if err != nil {
+			return ErrInvalidDefinition
+		}
+		def.baseDate = baseDate
+	}
+
+	if def.BaseValue <= 0 {
+		def.BaseValue = defaultBaseValue
+	}
+
+	if r := def.Rebalance; r != nil {
+		if r.Schedule != scheduleMonthly && r.Schedule != scheduleQuarterly {
+			return ErrInvalidDefinition
+		}
+
+		if r.WeightCap > 0 && r.Top > 0 && r.WeightCap*float64(r.Top) < 1 {
+			return ErrInvalidDefinition
+		}
+
+		if r.VolumeDays <= 0 {
+			r.VolumeDays = defaultVolumeDays
+		}
+
+		supplies := make(map[string]float64, len(r.Supplies))
+		for k, v := range r.Supplies {
+			supplies[strings.ToUpper(k)] = v
+		}
+		r.Supplies = supplies
+	}
+
+	def.Exchange = strings.ToUpper(def.Exchange)
+	def.Quote = strings.ToUpper(def.Quote)
+	for k := range def.Constituents {
+		def.Constituents[k].Currency = strings.ToUpper(def.Constituents[k].Currency)
+	}
+
+	return nil
+}
+
+// AttatchChannel replace index value send channel (default is redis)
+func (i *stIndex) AttatchChannel(ch chan model.IndexValue) {
+	i.chanIndex = ch
+}
+
+// Update recalculate every index by tickers and remove delisted constituents until ctx is cancelled
+func (i *stIndex) Update(ctx context.Context, tickers <-chan model.Ticker, markets <-chan model.MarketEvent) {
+	logger.Log.Info("[index.go] Start Update()")
+
+	tTicker := time.NewTicker(rebalanceCheckInterval)
+	defer tTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Log.Info("[index.go] End Update()")
+			return
+		case now := <-tTicker.C:
+			for _, ix := range i.indexes {
+				ix.rebalanceIfDue(now)
+			}
+		case event := <-markets:
+			if event.Type != model.MarketDelisting {
+				continue
+			}
+
+			for _, ix := range i.indexes {
+				delist(ix, event)
+			}
+		case ticker := <-tickers:
+			now := time.Now()
+			for _, ix := range i.indexes {
+				value, ok := ix.update(ticker, now)
+				if !ok {
+					continue
+				}
+
+				logger.Log.Info("[INDEX] ", value.Name, " ", value.Level)
+
+				if i.chanIndex == nil {
+					continue
+				}
+
+				select {
+				case i.chanIndex <- value:
+				case <-ctx.Done():
+				}
+			}
+		}
+	}
+}
+
+// SetConstituents replace constituents (currency : supply) of index, divisor is adjusted to keep level
+func (i *stIndex) SetConstituents(name string, supplies map[string]float64) error {
+	for _, ix := range i.indexes {
+		if ix.def.Name != name {
+			continue
+		}
+
+		_, err := ix.setConstituents(supplies, model.DivisorManual, time.Now())
+		return err
+	}
+
+	return ErrUnknownIndex
+}
+
+// Preview print proposed basket and turnover of rebalance rule now, constituents are not changed
+func (i *stIndex) Preview(name string) error {
+	for _, ix := range i.indexes {
+		if ix.def.Name != name {
+			continue
+		}
+
+		_, err := ix.preview(time.Now(), true)
+		return err
+	}
+
+	return ErrUnknownIndex
+}
+
+// delist remove delisted market from constituents of index
+func delist(ix *Index, event model.MarketEvent) {
+	def := &ix.def
+	if event.Exchange != def.Exchange || (def.Quote != "" && event.Quote != def.Quote) {
+		return
+	}
+
+	supplies := ix.constituents()
+	if _, ok := supplies[event.Currency]; !ok {
+		return
+	}
+	delete(supplies, event.Currency)
+
+	_, err := ix.setConstituents(supplies, model.DivisorDelisting, time.Now())
+	if err != nil {
+		logger.Log.Errorf("%s failed to remove delisted %s : %s", def.Name, event.Currency, err.Error())
+	}
+}
@@ -0,0 +1,37 @@
+package index
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func Test_LoadDefinitions(t *testing.T) {
+	defs, err := loadDefinitions("index.example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(defs) != 3 || defs[1].Methodology != methodologyEqual || defs[2].Methodology != methodologyCap {
+		t.Errorf("unexpected definitions %+v", defs)
+	}
+
+	// Single definition is still accepted, key defaults to name
+	path := filepath.Join(t.TempDir(), "index.json")
+	ioutil.WriteFile(path, []byte(`{"name": "CRIX", "exchange": "upbit", "constituents": [{"currency": "btc", "supply": 1}]}`), 0644)
+
+	defs, err = loadDefinitions(path)
+	if err != nil || len(defs) != 1 || defs[0].Key != "CRIX" || defs[0].Constituents[0].Currency != "BTC" {
+		t.Errorf("unexpected definitions %+v, %v", defs, err)
+	}
+
+	ioutil.WriteFile(path, []byte(`[
+		{"name": "A", "key": "crix", "exchange": "UPBIT", "constituents": [{"currency": "BTC", "supply": 1}]},
+		{"name": "B", "key": "crix", "exchange": "UPBIT", "constituents": [{"currency": "BTC", "supply": 1}]}
+	]`), 0644)
+
+	if _, err = loadDefinitions(path); !errors.Is(err, ErrInvalidDefinition) {
+		t.Errorf("expected ErrInvalidDefinition of duplicated key, got %v", err)
+	}
+}
@@ -0,0 +1,237 @@
+package index
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jeongpope/go-crix/logger"
+)
+
+var (
+	ErrImpossibleCap = errors.New("weight cap x constituents is under 100%")
+)
+
+const (
+	scheduleMonthly   = "monthly"
+	scheduleQuarterly = "quarterly"
+
+	defaultVolumeDays = 30
+)
+
+// RebalanceRule define rule based constituent selection and its schedule
+type RebalanceRule struct {
+	Schedule        string             `json:"schedule"`         // monthly, quarterly (first day 00:00 KST)
+	Top             int                `json:"top"`              // number of constituents, by average trade price
+	VolumeDays      int                `json:"volume_days"`      // days of average trade price (AccTradePrice24h), default 30
+	ExcludeWarnings []string           `json:"exclude_warnings"` // excluded market warnings (ex. CAUTION)
+	WeightCap       float64            `json:"weight_cap"`       // max weight of constituent (0.3 is 30%), 0 disables
+	DryRun          bool               `json:"dry_run"`          // print proposed basket only, constituents are not changed
+	Supplies        map[string]float64 `json:"supplies"`         // candidate currency : circulating supply
+}
+
+// candidate define selection input of currency
+type candidate struct {
+	currency string
+	price    float64
+	supply   float64
+	volume   float64 // average daily trade price
+	warning  string
+}
+
+// proposal define rebalance result
+type proposal struct {
+	supplies   map[string]float64 // currency : supply applied to index, capped weight is reflected
+	oldWeights map[string]float64
+	newWeights map[string]float64
+	turnover   float64
+}
+
+// periodKey returns rebalance period of t, rebalance runs once per period
+func periodKey(schedule string, t time.Time) string {
+	t = t.In(kst)
+
+	if schedule == scheduleQuarterly {
+		return fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())-1)/3+1)
+	}
+
+	return t.Format("2006-01")
+}
+
+// selectConstituents returns supplies of top candidates by volume, weights are capped by rule
+func selectConstituents(rule *RebalanceRule, candidates []candidate) (map[string]float64, error) {
+	excluded := make(map[string]struct{}, len(rule.ExcludeWarnings))
+	for _, v := range rule.ExcludeWarnings {
+		excluded[strings.ToUpper(v)] = struct{}{}
+	}
+
+	var selected []candidate
+	for _, c := range candidates {
+		if c.price <= 0 || c.supply <= 0 || c.volume <= 0 {
+			continue
+		}
+
+		if _, ok := excluded[strings.ToUpper(c.warning)]; ok {
+			continue
+		}
+
+		selected = append(selected, c)
+	}
+
+	sort.Slice(selected, func(i, j int) bool {
+		if selected[i].volume == selected[j].volume {
+			return selected[i].currency < selected[j].currency
+		}
+		return selected[i].volume > selected[j].volume
+	})
+
+	if rule.Top > 0 && len(selected) > rule.Top {
+		selected = selected[:rule.Top]
+	}
+
+	caps := make(map[string]float64, len(selected))
+	var total float64
+	for _, c := range selected {
+		caps[c.currency] = c.price * c.supply
+		total += c.price * c.supply
+	}
+
+	// Supply is scaled so market cap of constituent matches capped weight
+	weights, err := capWeights(caps, rule.WeightCap)
+	if err != nil {
+		return nil, fmt.Errorf("%w, %d constituents with cap %f", err, len(caps), rule.WeightCap)
+	}
+
+	supplies := make(map[string]float64, len(selected))
+	for _, c := range selected {
+		supplies[c.currency] = weights[c.currency] * total / c.price
+	}
+
+	return supplies, nil
+}
+
+// capWeights returns weights of market caps, weight above limit is cut
+// and excess is redistributed to others in proportion to market cap.
+// error if every weight cannot be under limit (limit x count < 1), 0 limit disables cap
+func capWeights(caps map[string]float64, limit float64) (map[string]float64, error) {
+	weights := normalizeWeights(caps)
+	if limit <= 0 || len(weights) == 0 {
+		return weights, nil
+	}
+
+	if limit*float64(len(caps)) < 1 {
+		return nil, ErrImpossibleCap
+	}
+
+	capped := make(map[string]bool)
+	for {
+		changed := false
+		for k, w := range weights {
+			if !capped[k] && w > limit {
+				capped[k] = true
+				changed = true
+			}
+		}
+
+		if !changed {
+			return weights, nil
+		}
+
+		var free float64
+		for k, v := range caps {
+			if !capped[k] {
+				free += v
+			}
+		}
+
+		remain := 1 - limit*float64(len(capped))
+		for k, v := range caps {
+			if capped[k] {
+				weights[k] = limit
+			} else {
+				weights[k] = v / free * remain
+			}
+		}
+	}
+}
+
+// normalizeWeights returns weights of market caps, empty if total is not positive
+func normalizeWeights(caps map[string]float64) map[string]float64 {
+	weights := make(map[string]float64, len(caps))
+
+	var total float64
+	for _, v := range caps {
+		total += v
+	}
+	if total <= 0 {
+		return weights
+	}
+
+	for k, v := range caps {
+		weights[k] = v / total
+	}
+
+	return weights
+}
+
+// turnover returns one way turnover between weights, 0.5 x sum |new - old|
+func turnover(oldWeights map[string]float64, newWeights map[string]float64) float64 {
+	var sum float64
+	for k, v := range newWeights {
+		sum += abs(v - oldWeights[k])
+	}
+	for k, v := range oldWeights {
+		if _, ok := newWeights[k]; !ok {
+			sum += v
+		}
+	}
+
+	return sum / 2
+}
+
+func abs(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// printProposal print proposed basket and turnover against current basket,
+// printed regardless of log level so dry-run is always visible
+func printProposal(name string, p *proposal, dryRun bool) {
+	mode := "apply"
+	if dryRun {
+		mode = "dry-run"
+	}
+
+	logger.Log.Printf("[rebalance.go] %s rebalance (%s), %d constituents, turnover %.2f%%",
+		name, mode, len(p.newWeights), p.turnover*100)
+
+	currencies := make([]string, 0, len(p.newWeights))
+	for k := range p.newWeights {
+		currencies = append(currencies, k)
+	}
+	sort.Slice(currencies, func(i, j int) bool { return p.newWeights[currencies[i]] > p.newWeights[currencies[j]] })
+
+	for _, k := range currencies {
+		logger.Log.Printf("[rebalance.go]   %-8s %7.2f%% (current %6.2f%%)", k, p.newWeights[k]*100, p.oldWeights[k]*100)
+	}
+
+	for k, v := range p.oldWeights {
+		if _, ok := p.newWeights[k]; !ok {
+			logger.Log.Printf("[rebalance.go]   %-8s removed (current %6.2f%%)", k, v*100)
+		}
+	}
+}
+
+// weightsOf returns weights of supplies at prices
+func weightsOf(supplies map[string]float64, prices map[string]float64) map[string]float64 {
+	caps := make(map[string]float64, len(supplies))
+	for k, v := range supplies {
+		caps[k] = v * prices[k]
+	}
+
+	return normalizeWeights(caps)
+}
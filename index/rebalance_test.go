@@ -0,0 +1,152 @@
+package index
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jeongpope/go-crix/logger"
+	"github.com/jeongpope/go-crix/model"
+)
+
+func Test_SelectConstituents(t *testing.T) {
+	rule := &RebalanceRule{Top: 2, ExcludeWarnings: []string{"CAUTION"}, WeightCap: 0.6}
+	candidates := []candidate{
+		{currency: "BTC", price: 100, supply: 9, volume: 300},
+		{currency: "ETH", price: 10, supply: 10, volume: 200},
+		{currency: "XRP", price: 1, supply: 100, volume: 500, warning: "CAUTION"},
+		{currency: "SOL", price: 10, supply: 1, volume: 100},
+	}
+
+	supplies, err := selectConstituents(rule, candidates)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(supplies) != 2 || supplies["BTC"] == 0 || supplies["ETH"] == 0 {
+		t.Fatalf("unexpected constituents %v", supplies)
+	}
+
+	// BTC 900 / ETH 100 is capped to 60% / 40% of total 1000
+	prices := map[string]float64{"BTC": 100, "ETH": 10}
+	weights := weightsOf(supplies, prices)
+	if math.Abs(weights["BTC"]-0.6) > 1e-9 || math.Abs(weights["ETH"]-0.4) > 1e-9 {
+		t.Errorf("unexpected weights %v", weights)
+	}
+}
+
+func Test_CapWeights(t *testing.T) {
+	weights, err := capWeights(map[string]float64{"A": 70, "B": 20, "C": 10}, 0.4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]float64{"A": 0.4, "B": 0.4, "C": 0.2}
+	for k, v := range expected {
+		if math.Abs(weights[k]-v) > 1e-9 {
+			t.Errorf("weight of %s = %f, expected %f", k, weights[k], v)
+		}
+	}
+
+	// 3 x 30% cannot hold 100%
+	if _, err := capWeights(map[string]float64{"A": 70, "B": 20, "C": 10}, 0.3); !errors.Is(err, ErrImpossibleCap) {
+		t.Errorf("expected ErrImpossibleCap, got %v", err)
+	}
+}
+
+func Test_Turnover(t *testing.T) {
+	value := turnover(map[string]float64{"A": 0.5, "B": 0.5}, map[string]float64{"A": 0.5, "C": 0.5})
+	if math.Abs(value-0.5) > 1e-9 {
+		t.Errorf("turnover = %f, expected 0.5", value)
+	}
+}
+
+func Test_PeriodKey(t *testing.T) {
+	// 2024-03-31 16:00 UTC is 2024-04-01 01:00 KST
+	now := time.Date(2024, 3, 31, 16, 0, 0, 0, time.UTC)
+
+	if key := periodKey(scheduleMonthly, now); key != "2024-04" {
+		t.Errorf("monthly key = %s", key)
+	}
+
+	if key := periodKey(scheduleQuarterly, now); key != "2024-Q2" {
+		t.Errorf("quarterly key = %s", key)
+	}
+}
+
+func Test_RebalanceDryRunAtStart(t *testing.T) {
+	rule := &RebalanceRule{Schedule: scheduleMonthly, DryRun: true, VolumeDays: defaultVolumeDays, Supplies: map[string]float64{"BTC": 1, "ETH": 10}}
+	ix := newIndex(Definition{
+		Name:         "CRIX",
+		Exchange:     "UPBIT",
+		BaseValue:    1000,
+		Constituents: []ConstituentDefinition{{Currency: "BTC", Supply: 1}},
+		Rebalance:    rule,
+	}, nil)
+	ix.volumes = newVolumeHistory("", defaultVolumeDays)
+	now := time.Now()
+
+	ix.update(model.Ticker{Exchange: "UPBIT", Currency: "BTC", Price: 100, Volume: 10}, now)
+	ix.update(model.Ticker{Exchange: "UPBIT", Currency: "ETH", Price: 10, Volume: 5}, now)
+
+	// Dry-run proposal is printed at first check, constituents are kept
+	ix.rebalanceIfDue(now)
+	if !ix.previewed || ix.rebalanced == "" || len(ix.constituents()) != 1 {
+		t.Errorf("unexpected state after dry-run, previewed %t, period %s, %v", ix.previewed, ix.rebalanced, ix.constituents())
+	}
+
+	p, err := ix.preview(now, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Proposal keeps supply of BTC and adds ETH at equal market cap
+	if len(p.supplies) != 2 || math.Abs(p.supplies["BTC"]-1) > 1e-9 || math.Abs(p.supplies["ETH"]-10) > 1e-9 {
+		t.Errorf("unexpected proposed supplies %v", p.supplies)
+	}
+	if math.Abs(p.newWeights["BTC"]-0.5) > 1e-9 || math.Abs(p.newWeights["ETH"]-0.5) > 1e-9 {
+		t.Errorf("unexpected proposed weights %v", p.newWeights)
+	}
+	if math.Abs(p.turnover-0.5) > 1e-9 {
+		t.Errorf("expected turnover 0.5, got %f", p.turnover)
+	}
+}
+
+func Test_RebalanceSkippedWithoutVolume(t *testing.T) {
+	rule := &RebalanceRule{Schedule: scheduleMonthly, VolumeDays: defaultVolumeDays, Supplies: map[string]float64{"BTC": 1, "ETH": 10}}
+	ix := newIndex(Definition{
+		Name:         "CRIX",
+		Exchange:     "UPBIT",
+		BaseValue:    1000,
+		Constituents: []ConstituentDefinition{{Currency: "BTC", Supply: 1}},
+		Rebalance:    rule,
+	}, nil)
+	ix.volumes = newVolumeHistory("", defaultVolumeDays)
+	now := time.Now()
+
+	// Prices are known but volume history is empty at first start
+	ix.prices["BTC"] = 100
+	ix.prices["ETH"] = 10
+
+	if _, err := ix.preview(now, false); !errors.Is(err, ErrNoCandidate) {
+		t.Fatalf("expected ErrNoCandidate, got %v", err)
+	}
+
+	var out bytes.Buffer
+	logger.SetOut(&out)
+	defer logger.SetOut(os.Stderr)
+
+	// Previous period is over so rebalance is due, skip is logged and constituents are kept
+	ix.rebalanced = "2000-01"
+	ix.rebalanceIfDue(now)
+	if !strings.Contains(out.String(), "rebalance skipped") {
+		t.Errorf("expected skipped rebalance to be logged, got %q", out.String())
+	}
+	if c := ix.constituents(); len(c) != 1 || c["BTC"] != 1 {
+		t.Errorf("constituents changed on skipped rebalance, %v", c)
+	}
+}
@@ -0,0 +1,133 @@
+package index
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+var kst = time.FixedZone("KST", 9*60*60) // upbit trade day starts at korea midnight
+
+// volumeHistory keep daily trade price (AccTradePrice24h) per market,
+// the last sample of each korea day is kept for days, shared by every index
+type volumeHistory struct {
+	path string // optional, saved when day changes
+	days int
+
+	lock    *sync.Mutex
+	samples map[string]map[string]float64 // market (EXCHANGE:QUOTE:CURRENCY) : day (2006-01-02) : volume
+	lastDay string
+}
+
+func newVolumeHistory(path string, days int) *volumeHistory {
+	return &volumeHistory{
+		path:    path,
+		days:    days,
+		lock:    &sync.Mutex{},
+		samples: make(map[string]map[string]float64),
+	}
+}
+
+func volumeKey(exchange string, quote string, currency string) string {
+	return exchange + ":" + quote + ":" + currency
+}
+
+// add record volume of market, previous days are pruned and saved when day changes
+func (h *volumeHistory) add(market string, volume float64, now time.Time) error {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	day := now.In(kst).Format("2006-01-02")
+
+	var err error
+	if h.lastDay != "" && h.lastDay != day {
+		h.prune(now)
+		err = h.save()
+	}
+	h.lastDay = day
+
+	daily, ok := h.samples[market]
+	if !ok {
+		daily = make(map[string]float64)
+		h.samples[market] = daily
+	}
+	daily[day] = volume
+
+	return err
+}
+
+// average returns average daily volume of market for last days and number of sampled days
+func (h *volumeHistory) average(market string, days int, now time.Time) (float64, int) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	oldest := oldestDay(now, days)
+
+	var total float64
+	var count int
+	for day, v := range h.samples[market] {
+		if day < oldest {
+			continue
+		}
+
+		total += v
+		count++
+	}
+
+	if count == 0 {
+		return 0, 0
+	}
+
+	return total / float64(count), count
+}
+
+func (h *volumeHistory) prune(now time.Time) {
+	oldest := oldestDay(now, h.days)
+
+	for market, daily := range h.samples {
+		for day := range daily {
+			if day < oldest {
+				delete(daily, day)
+			}
+		}
+
+		if len(daily) == 0 {
+			delete(h.samples, market)
+		}
+	}
+}
+
+func oldestDay(now time.Time, days int) string {
+	return now.In(kst).AddDate(0, 0, -days+1).Format("2006-01-02")
+}
+
+func (h *volumeHistory) load() error {
+	if h.path == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(h.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, &h.samples)
+}
+
+func (h *volumeHistory) save() error {
+	if h.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(h.samples)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(h.path, data, 0644)
+}
@@ -3,9 +3,92 @@ package model
 type Ticker struct {
 	Exchange       string  `json:"exchange"`
 	Currency       string  `json:"currency"`
+	Quote          string  `json:"quote,omitempty"`
 	Price          float64 `json:"price"`
 	YesterdayPrice float64 `json:"yesterday_price"`
 	Change         float64 `json:"change"`
 	ChangeRate     float64 `json:"change_rate"`
 	Volume         uint    `json:"volume"`
+	MarketWarning  string  `json:"market_warning,omitempty"` // upbit market warning (NONE, CAUTION)
+}
+
+type OrderBookUnit struct {
+	Price float64 `json:"price"`
+	Size  float64 `json:"size"`
+}
+
+type OrderBook struct {
+	Exchange     string          `json:"exchange"`
+	Currency     string          `json:"currency"`
+	Quote        string          `json:"quote,omitempty"`
+	Asks         []OrderBookUnit `json:"asks"` // ascending by price
+	Bids         []OrderBookUnit `json:"bids"` // descending by price
+	TotalAskSize float64         `json:"total_ask_size"`
+	TotalBidSize float64         `json:"total_bid_size"`
+	Timestamp    int64           `json:"timestamp"` // milliseconds
+}
+
+type Trade struct {
+	Exchange     string  `json:"exchange"`
+	Currency     string  `json:"currency"`
+	Quote        string  `json:"quote,omitempty"`
+	Price        float64 `json:"price"`
+	Volume       float64 `json:"volume"`
+	Side         string  `json:"side"` // ASK(sell), BID(buy)
+	SequentialID int64   `json:"sequential_id"`
+	Timestamp    int64   `json:"timestamp"` // trade timestamp (milliseconds)
+}
+
+const (
+	MarketListing   = "listing"   // market is newly listed
+	MarketDelisting = "delisting" // market is delisted or delisting date passed
+)
+
+type MarketEvent struct {
+	Exchange      string `json:"exchange"`
+	Code          string `json:"code"` // exchange market code (ex. KRW-BTC)
+	Currency      string `json:"currency"`
+	Quote         string `json:"quote,omitempty"`
+	Type          string `json:"type"`                     // MarketListing, MarketDelisting
+	DelistingDate string `json:"delisting_date,omitempty"` // announced delisting date (yyyy-mm-dd)
+	Timestamp     int64  `json:"timestamp"`                // detected time (milliseconds)
+}
+
+type IndexConstituent struct {
+	Currency     string  `json:"currency"`
+	Price        float64 `json:"price"`
+	Supply       float64 `json:"supply"`
+	MarketCap    float64 `json:"market_cap"`   // price x supply
+	Weight       float64 `json:"weight"`       // ratio of total market cap
+	Contribution float64 `json:"contribution"` // index points (market cap / divisor)
+}
+
+type IndexValue struct {
+	Name         string             `json:"name"`
+	Key          string             `json:"key"` // output key, redis CRIX:INDEX:<key> and amqp queue
+	Level        float64            `json:"level"`
+	Divisor      float64            `json:"divisor"`
+	Constituents []IndexConstituent `json:"constituents"`
+	Timestamp    int64              `json:"timestamp"` // milliseconds
+}
+
+const (
+	DivisorInitial   = "initial"   // divisor set when calculation starts
+	DivisorDelisting = "delisting" // constituent removed by delisting
+	DivisorManual    = "manual"    // constituents changed by operator
+	DivisorRebalance = "rebalance" // constituents selected by scheduled rebalance
+)
+
+type DivisorAdjustment struct {
+	Name         string             `json:"name"`
+	Reason       string             `json:"reason"` // DivisorInitial, DivisorDelisting, DivisorManual, DivisorRebalance
+	OldDivisor   float64            `json:"old_divisor"`
+	NewDivisor   float64            `json:"new_divisor"`
+	Level        float64            `json:"level"` // level kept through adjustment
+	Added        []string           `json:"added,omitempty"`
+	Removed      []string           `json:"removed,omitempty"`
+	Reweighted   []string           `json:"reweighted,omitempty"`
+	Constituents map[string]float64 `json:"constituents"`     // currency : supply after adjustment
+	Config       map[string]float64 `json:"config,omitempty"` // currency : supply of config file, detects edited config on restore
+	Timestamp    int64              `json:"timestamp"`        // milliseconds
 }
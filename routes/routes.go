@@ -1,13 +1,165 @@
 package routes
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jeongpope/go-crix/exchange"
+	"github.com/jeongpope/go-crix/index"
 	"github.com/jeongpope/go-crix/logger"
 )
 
+var server *http.Server
+
+// exchanges define operations of running exchanges used by routes
+type exchanges interface {
+	Subscribe(name string, codes []string) error
+	Unsubscribe(name string, codes []string) error
+	GetSupportAssets() []string
+	GetWebsocketStats() map[string]exchange.WebsocketStat
+}
+
+// indexes define operations of index used by routes
+type indexes interface {
+	Preview(name string) error
+}
+
+// Status define response of GET /status
+type Status struct {
+	Assets    []string                          `json:"assets"`    // merged assets of running exchanges
+	Websocket map[string]exchange.WebsocketStat `json:"websocket"` // connection stats and mode (streaming, polling)
+}
+
+// Initialize start admin HTTP server, must be called after exchange and index are initialized
+//
+// GOCRIX_ADMIN_ADDR : listen address (ex. 127.0.0.1:8080), admin server is disabled if not set
+//
+//	GET  /status                                          assets and websocket stats
+//	POST /subscribe?exchange=UPBIT&codes=KRW-BTC,KRW-ETH  subscribe markets (exchanges supporting runtime subscription, upbit)
+//	POST /unsubscribe?exchange=UPBIT&codes=KRW-BTC        unsubscribe markets
+//	POST /index/preview?name=CRIX-10                      print rebalance proposal (dry-run)
 func Initialize() {
 	logger.Log.Info("[routes.go] Start Initialize()")
 
-	logger.Log.Info("[routes.go] Anything..")
+	addr := os.Getenv("GOCRIX_ADMIN_ADDR")
+	if addr == "" {
+		logger.Log.Info("[routes.go] GOCRIX_ADMIN_ADDR is not set, admin server disabled")
+		return
+	}
+
+	server = &http.Server{
+		Addr:    addr,
+		Handler: newHandler(exchange.GetInstance(), index.GetInstance()),
+	}
+
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			logger.Log.Error("Admin server stopped : ", err)
+		}
+	}()
 
 	logger.Log.Info("[routes.go] End Initialize())")
 }
+
+// Release shutdown admin HTTP server
+func Release() {
+	if server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	server.Shutdown(ctx)
+}
+
+func newHandler(ex exchanges, ix indexes) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		writeJSON(w, Status{
+			Assets:    ex.GetSupportAssets(),
+			Websocket: ex.GetWebsocketStats(),
+		})
+	})
+
+	mux.HandleFunc("/subscribe", subscription(ex.Subscribe))
+	mux.HandleFunc("/unsubscribe", subscription(ex.Unsubscribe))
+
+	mux.HandleFunc("/index/preview", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		err := ix.Preview(r.URL.Query().Get("name"))
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return mux
+}
+
+// subscription returns handler of subscribe and unsubscribe
+func subscription(change func(name string, codes []string) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		name := strings.ToUpper(r.URL.Query().Get("exchange"))
+		var codes []string
+		for _, v := range strings.Split(r.URL.Query().Get("codes"), ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				codes = append(codes, v)
+			}
+		}
+
+		if name == "" || len(codes) == 0 {
+			http.Error(w, "exchange and codes are required", http.StatusBadRequest)
+			return
+		}
+
+		err := change(name, codes)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		logger.Log.Printf("[routes.go] %s %s %v", r.URL.Path, name, codes)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, exchange.ErrNotRunning), errors.Is(err, index.ErrUnknownIndex):
+		status = http.StatusNotFound
+	case errors.Is(err, exchange.ErrNotSupported):
+		status = http.StatusNotImplemented
+	}
+
+	http.Error(w, err.Error(), status)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
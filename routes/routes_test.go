@@ -0,0 +1,88 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/jeongpope/go-crix/exchange"
+	"github.com/jeongpope/go-crix/index"
+)
+
+type fakeExchanges struct {
+	subscribed []string
+}
+
+func (f *fakeExchanges) Subscribe(name string, codes []string) error {
+	if name != "UPBIT" {
+		return exchange.ErrNotSupported
+	}
+
+	f.subscribed = append(f.subscribed, codes...)
+	return nil
+}
+
+func (f *fakeExchanges) Unsubscribe(name string, codes []string) error {
+	return exchange.ErrNotRunning
+}
+
+func (f *fakeExchanges) GetSupportAssets() []string {
+	return []string{"BTC"}
+}
+
+func (f *fakeExchanges) GetWebsocketStats() map[string]exchange.WebsocketStat {
+	return map[string]exchange.WebsocketStat{"wss://api.upbit.com/websocket/v1#shard-0": {Mode: "polling"}}
+}
+
+type fakeIndexes struct{}
+
+func (fakeIndexes) Preview(name string) error {
+	return index.ErrUnknownIndex
+}
+
+func Test_Subscription(t *testing.T) {
+	ex := &fakeExchanges{}
+	handler := newHandler(ex, fakeIndexes{})
+
+	cases := []struct {
+		method string
+		url    string
+		status int
+	}{
+		{http.MethodPost, "/subscribe?exchange=upbit&codes=KRW-BTC,%20KRW-ETH", http.StatusNoContent},
+		{http.MethodPost, "/subscribe?exchange=BINANCE&codes=BTCUSDT", http.StatusNotImplemented},
+		{http.MethodPost, "/unsubscribe?exchange=KORBIT&codes=BTC", http.StatusNotFound},
+		{http.MethodPost, "/subscribe?exchange=UPBIT", http.StatusBadRequest},
+		{http.MethodGet, "/subscribe?exchange=UPBIT&codes=KRW-BTC", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/index/preview?name=NONE", http.StatusNotFound},
+	}
+
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(c.method, c.url, nil))
+
+		if w.Code != c.status {
+			t.Errorf("%s %s = %d, expected %d", c.method, c.url, w.Code, c.status)
+		}
+	}
+
+	if !reflect.DeepEqual(ex.subscribed, []string{"KRW-BTC", "KRW-ETH"}) {
+		t.Errorf("subscribed %v", ex.subscribed)
+	}
+}
+
+func Test_Status(t *testing.T) {
+	w := httptest.NewRecorder()
+	newHandler(&fakeExchanges{}, fakeIndexes{}).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	var status Status
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatal(err)
+	}
+
+	if status.Websocket["wss://api.upbit.com/websocket/v1#shard-0"].Mode != "polling" || len(status.Assets) != 1 {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
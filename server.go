@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/jeongpope/go-crix/exchange"
 	"github.com/jeongpope/go-crix/goredis"
+	"github.com/jeongpope/go-crix/index"
 	"github.com/jeongpope/go-crix/logger"
 	"github.com/jeongpope/go-crix/routes"
 )
@@ -14,6 +18,7 @@ import (
 var (
 	ErrFailedInitRedis    = errors.New("failed to initialize redis")
 	ErrFailedInitExchange = errors.New("failed to initialize exchange")
+	ErrFailedInitIndex    = errors.New("failed to initialize index")
 )
 
 func main() {
@@ -26,7 +31,11 @@ func main() {
 		return
 	}
 
-	update()
+	// Cancelled by SIGINT, SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	update(ctx)
 	release()
 
 	logger.Log.Info("[server.go] End main()")
@@ -45,9 +54,6 @@ func initialize() error {
 	logger.Log.SetOut(f)
 	logger.Log.SetLevel(logger.ERROR)
 
-	// Routes
-	routes.Initialize()
-
 	// Redis
 	if goredis.GetInstance() == nil {
 		return ErrFailedInitRedis
@@ -58,15 +64,24 @@ func initialize() error {
 		return ErrFailedInitExchange
 	}
 
+	// Index
+	if index.GetInstance() == nil {
+		return ErrFailedInitIndex
+	}
+
+	// Routes, admin server uses exchange and index
+	routes.Initialize()
+
 	logger.Log.Info("[server.go] End initialize()")
 	return nil
 }
 
-func update() {
+func update(ctx context.Context) {
 	logger.Log.Info("[server.go] Start update()")
 
 	goredis.GetInstance().Update()
-	exchange.GetInstance().Update()
+	go index.GetInstance().Update(ctx, exchange.GetInstance().Listen(), exchange.GetInstance().ListenMarket())
+	exchange.GetInstance().Update(ctx)
 
 	logger.Log.Info("[server.go] End update()")
 }
@@ -74,5 +89,8 @@ func update() {
 func release() {
 	logger.Log.Info("[server.go] Start release()")
 
+	routes.Release()
+	exchange.GetInstance().Release()
+
 	logger.Log.Info("[server.go] End release()")
 }
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+)
+
+// IntEnv returns environment integer value, defaultValue if not set or invalid
+func IntEnv(key string, defaultValue int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || v < 0 {
+		return defaultValue
+	}
+
+	return v
+}
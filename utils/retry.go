@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var ErrMaxAttempts = errors.New("max retry attempts exceeded")
+
+var (
+	randLock = &sync.Mutex{}
+	random   = rand.New(rand.NewSource(time.Now().UnixNano())) // seeded per process, instances must not retry in lockstep
+)
+
+// RetryPolicy define exponential backoff with jitter
+type RetryPolicy struct {
+	Initial     time.Duration // first retry interval
+	Max         time.Duration // retry interval cap
+	Multiplier  float64       // interval multiplier per attempt
+	Jitter      float64       // random ratio applied to interval (0.2 : ±20%)
+	MaxAttempts int           // give up after attempts, 0 is unlimited
+
+	OnRetry func(attempt int, delay time.Duration, err error) // optional, called before sleep
+}
+
+// DefaultRetryPolicy returns policy configured by environment
+// GOCRIX_RETRY_INITIAL : first retry interval (second), default 1, at least 1
+// GOCRIX_RETRY_MAX : retry interval cap (second), default 60, at least initial
+// GOCRIX_RETRY_MAX_ATTEMPTS : give up after attempts, default 0 (unlimited)
+func DefaultRetryPolicy() *RetryPolicy {
+	// Zero interval would retry in busy loop, fall back to default
+	initial := IntEnv("GOCRIX_RETRY_INITIAL", 1)
+	if initial <= 0 {
+		initial = 1
+	}
+	max := IntEnv("GOCRIX_RETRY_MAX", 60)
+	if max < initial {
+		max = initial
+	}
+
+	return &RetryPolicy{
+		Initial:     time.Second * time.Duration(initial),
+		Max:         time.Second * time.Duration(max),
+		Multiplier:  2,
+		Jitter:      0.2,
+		MaxAttempts: IntEnv("GOCRIX_RETRY_MAX_ATTEMPTS", 0),
+	}
+}
+
+// WithOnRetry returns copy of policy with retry callback
+func (p *RetryPolicy) WithOnRetry(onRetry func(attempt int, delay time.Duration, err error)) *RetryPolicy {
+	policy := *p
+	policy.OnRetry = onRetry
+
+	return &policy
+}
+
+// Backoff returns delay after attempt (1 based)
+func (p *RetryPolicy) Backoff(attempt int) time.Duration {
+	delay := float64(p.Initial)
+	for i := 1; i < attempt && delay < float64(p.Max); i++ {
+		delay *= p.Multiplier
+	}
+
+	if delay > float64(p.Max) {
+		delay = float64(p.Max)
+	}
+
+	if p.Jitter > 0 {
+		randLock.Lock()
+		delay += (random.Float64()*2 - 1) * p.Jitter * delay
+		randLock.Unlock()
+	}
+
+	return time.Duration(delay)
+}
+
+// Retry call fn until it returns nil, returns ErrMaxAttempts wrapped error when attempts exceeded
+func (p *RetryPolicy) Retry(fn func() error) error {
+	return p.RetryContext(context.Background(), fn)
+}
+
+// RetryContext is Retry stopped by ctx, returns ctx.Err() when cancelled while waiting
+func (p *RetryPolicy) RetryContext(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+			return fmt.Errorf("%w, %s", ErrMaxAttempts, err.Error())
+		}
+
+		delay := p.Backoff(attempt)
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, delay, err)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func Test_RetryPolicyBackoff(t *testing.T) {
+	p := &RetryPolicy{Initial: time.Second, Max: time.Second * 10, Multiplier: 2}
+
+	expected := []time.Duration{time.Second, time.Second * 2, time.Second * 4, time.Second * 8, time.Second * 10, time.Second * 10}
+	for i, v := range expected {
+		if d := p.Backoff(i + 1); d != v {
+			t.Errorf("Backoff(%d) = %s, expected %s", i+1, d, v)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := p.Backoff(2); d < time.Second || d > time.Second*3 {
+			t.Fatalf("Backoff(2) with jitter = %s, out of range", d)
+		}
+	}
+}
+
+func Test_DefaultRetryPolicyZeroInterval(t *testing.T) {
+	os.Setenv("GOCRIX_RETRY_INITIAL", "0")
+	os.Setenv("GOCRIX_RETRY_MAX", "0")
+	defer os.Unsetenv("GOCRIX_RETRY_INITIAL")
+	defer os.Unsetenv("GOCRIX_RETRY_MAX")
+
+	p := DefaultRetryPolicy()
+	if p.Initial != time.Second || p.Max != time.Second {
+		t.Errorf("expected interval clamped to 1s, initial %s, max %s", p.Initial, p.Max)
+	}
+}
+
+func Test_RetryPolicyMaxAttempts(t *testing.T) {
+	var retried int
+	p := &RetryPolicy{Initial: time.Millisecond, Max: time.Millisecond, Multiplier: 2, MaxAttempts: 3,
+		OnRetry: func(attempt int, delay time.Duration, err error) { retried++ }}
+
+	calls := 0
+	err := p.Retry(func() error {
+		calls++
+		return errors.New("failed")
+	})
+
+	if !errors.Is(err, ErrMaxAttempts) || calls != 3 || retried != 2 {
+		t.Fatalf("err %v, calls %d, retried %d", err, calls, retried)
+	}
+
+	calls = 0
+	err = p.Retry(func() error {
+		calls++
+		if calls < 2 {
+			return errors.New("failed")
+		}
+		return nil
+	})
+
+	if err != nil || calls != 2 {
+		t.Fatalf("err %v, calls %d", err, calls)
+	}
+}
+
+func Test_RetryPolicyContext(t *testing.T) {
+	p := &RetryPolicy{Initial: time.Hour, Max: time.Hour, Multiplier: 2}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*10, cancel)
+
+	err := p.RetryContext(ctx, func() error {
+		return errors.New("failed")
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err %v, expected context.Canceled", err)
+	}
+}